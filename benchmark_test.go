@@ -0,0 +1,233 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The regex based parser and the string concatenating serializer of earlier versions,
+// kept here to compare them against the current implementation.
+var (
+	legacyPriorityRx      = regexp.MustCompile(`^(x|x \d{4}-\d{2}-\d{2}|)\s*\(([A-Z])\)\s+`)
+	legacyCreatedDateRx   = regexp.MustCompile(`^(\([A-Z]\)|x \d{4}-\d{2}-\d{2} \([A-Z]\)|x \([A-Z]\)|x \d{4}-\d{2}-\d{2}|)\s*(\d{4}-\d{2}-\d{2})\s+`)
+	legacyCompletedRx     = regexp.MustCompile(`^x\s+`)
+	legacyCompletedDateRx = regexp.MustCompile(`^x\s*(\d{4}-\d{2}-\d{2})\s+`)
+	legacyAddonTagRx      = regexp.MustCompile(`(^|\s+)([\w-]+):(\S+)`)
+	legacyContextRx       = regexp.MustCompile(`(^|\s+)@(\S+)`)
+	legacyProjectRx       = regexp.MustCompile(`(^|\s+)\+(\S+)`)
+)
+
+func legacyParseTask(text string) (*Task, error) {
+	task := Task{}
+	task.Original = strings.Trim(text, "\t\n\r ")
+	task.Todo = task.Original
+
+	if legacyCompletedRx.MatchString(task.Original) {
+		task.Completed = true
+		if legacyCompletedDateRx.MatchString(task.Original) {
+			date, err := time.Parse(DateLayout, legacyCompletedDateRx.FindStringSubmatch(task.Original)[1])
+			if err != nil {
+				return nil, err
+			}
+			task.CompletedDate = date
+		}
+		task.Todo = legacyCompletedDateRx.ReplaceAllString(task.Todo, "")
+		task.Todo = legacyCompletedRx.ReplaceAllString(task.Todo, "")
+	}
+
+	if legacyPriorityRx.MatchString(task.Original) {
+		task.Priority = legacyPriorityRx.FindStringSubmatch(task.Original)[2]
+		task.Todo = legacyPriorityRx.ReplaceAllString(task.Todo, "")
+	}
+
+	if legacyCreatedDateRx.MatchString(task.Original) {
+		date, err := time.Parse(DateLayout, legacyCreatedDateRx.FindStringSubmatch(task.Original)[2])
+		if err != nil {
+			return nil, err
+		}
+		task.CreatedDate = date
+		task.Todo = legacyCreatedDateRx.ReplaceAllString(task.Todo, "")
+	}
+
+	getSlice := func(rx *regexp.Regexp) []string {
+		matches := rx.FindAllStringSubmatch(task.Original, -1)
+		slice := make([]string, 0, len(matches))
+		seen := make(map[string]bool, len(matches))
+		for _, match := range matches {
+			word := strings.Trim(match[2], "\t\n\r ")
+			if !seen[word] {
+				slice = append(slice, word)
+				seen[word] = true
+			}
+		}
+		sort.Strings(slice)
+		return slice
+	}
+
+	if legacyContextRx.MatchString(task.Original) {
+		task.Contexts = getSlice(legacyContextRx)
+		task.Todo = legacyContextRx.ReplaceAllString(task.Todo, "")
+	}
+
+	if legacyProjectRx.MatchString(task.Original) {
+		task.Projects = getSlice(legacyProjectRx)
+		task.Todo = legacyProjectRx.ReplaceAllString(task.Todo, "")
+	}
+
+	if legacyAddonTagRx.MatchString(task.Original) {
+		matches := legacyAddonTagRx.FindAllStringSubmatch(task.Original, -1)
+		tags := make(map[string]string, len(matches))
+		for _, match := range matches {
+			key, value := match[2], match[3]
+			if key == "due" {
+				date, err := time.Parse(DateLayout, value)
+				if err != nil {
+					return nil, err
+				}
+				task.DueDate = date
+			} else if key != "" && value != "" {
+				tags[key] = value
+			}
+		}
+		task.AdditionalTags = tags
+		task.Todo = legacyAddonTagRx.ReplaceAllString(task.Todo, "")
+	}
+
+	task.Todo = strings.Trim(task.Todo, "\t\n\r\f ")
+	return &task, nil
+}
+
+func legacyFormatList(tasklist TaskList) (text string) {
+	for _, task := range tasklist {
+		text += task.String() + "\n"
+	}
+	return text
+}
+
+// benchmarkLines returns the lines of the test tasklist, repeated until there are n of them.
+func benchmarkLines(tb testing.TB, n int) []string {
+	data, err := ioutil.ReadFile(testInputTasklist)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sample := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = sample[i%len(sample)]
+	}
+	return lines
+}
+
+var benchmarkSizes = []int{1000, 100000, 1000000}
+
+func TestParseTaskMatchesLegacyParser(t *testing.T) {
+	for _, line := range benchmarkLines(t, 64) {
+		task, err := ParseTask(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		legacy, err := legacyParseTask(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !task.equals(legacy) {
+			t.Errorf("Expected [%s] to be parsed as [%v], but got [%v]", line, legacy, task)
+		}
+	}
+}
+
+func BenchmarkParseTask(b *testing.B) {
+	lines := benchmarkLines(b, 64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseTask(lines[i%len(lines)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseTaskLegacy(b *testing.B) {
+	lines := benchmarkLines(b, 64)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyParseTask(lines[i%len(lines)]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadFromReader(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		input := strings.Join(benchmarkLines(b, size), "\n")
+		b.Run(fmt.Sprintf("lines=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				if _, err := LoadFromReader(strings.NewReader(input)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkLoadFromReaderLegacy(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		input := strings.Join(benchmarkLines(b, size), "\n")
+		b.Run(fmt.Sprintf("lines=%d", size), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			for i := 0; i < b.N; i++ {
+				tasklist := TaskList{}
+				scanner := bufio.NewScanner(strings.NewReader(input))
+				for scanner.Scan() {
+					task, err := legacyParseTask(scanner.Text())
+					if err != nil {
+						b.Fatal(err)
+					}
+					tasklist = append(tasklist, *task)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkTaskListString(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		tasklist, err := LoadFromReader(strings.NewReader(strings.Join(benchmarkLines(b, size), "\n")))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("lines=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = tasklist.String()
+			}
+		})
+	}
+}
+
+// BenchmarkTaskListStringLegacy stops at 10000 lines, the quadratic concatenation takes minutes for more.
+func BenchmarkTaskListStringLegacy(b *testing.B) {
+	for _, size := range []int{1000, 10000} {
+		tasklist, err := LoadFromReader(strings.NewReader(strings.Join(benchmarkLines(b, size), "\n")))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("lines=%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = legacyFormatList(tasklist)
+			}
+		})
+	}
+}
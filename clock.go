@@ -0,0 +1,45 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"time"
+)
+
+// Clock provides the current time to all time dependent functions, like NewTask(), *Task.Complete() or *Task.IsOverdue().
+//
+// Replacing it allows to ask questions about other points in time, like "what will be overdue next Monday?",
+// and makes tests independent of the time they are run at. See package todotxttest for fixed and fake clocks.
+//
+// A Clock can be set per operation, using the ...With() variants of the time dependent functions.
+// As a TaskList is a plain slice, it can not hold a Clock of its own. Instead the tasks use the Parser.Clock
+// of the Parser they were read with, which makes it the Clock of a whole TaskList or Document.
+// Passing a nil Clock to a ...With() variant also uses the Clock of each task.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is a Clock returning the current system time.
+type SystemClock struct{}
+
+// Now returns time.Now().
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// DefaultClock is used by all time dependent functions that are not given a Clock explicitly,
+// unless the task was read by a Parser with its own Parser.Clock.
+var DefaultClock Clock = SystemClock{}
+
+// clockNow returns the current time of clock, falling back to DefaultClock and SystemClock if it is nil.
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		clock = DefaultClock
+	}
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
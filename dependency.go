@@ -0,0 +1,246 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"container/heap"
+	"strings"
+)
+
+// DependencyGraph connects the tasks of a TaskList through their 'id:' and 'dep:' tags.
+//
+// A task with 'dep:abc' depends on the task with 'id:abc', several dependencies are separated by commas: 'dep:abc,def'
+// The graph is a snapshot, it has to be built again after the TaskList was modified.
+type DependencyGraph struct {
+	ids        []int          // Task ids, in TaskList order
+	refs       map[int]string // Task id -> 'id:' tag value
+	blockers   map[int][]int  // Task id -> ids of the tasks it depends on
+	dependents map[int][]int  // Task id -> ids of the tasks depending on it
+	completed  map[int]bool
+}
+
+// DependencyId returns the value of the 'id:' tag of the task, which other tasks use to depend on it.
+func (task *Task) DependencyId() string {
+	return task.AdditionalTags["id"]
+}
+
+// Dependencies returns the values of the 'dep:' tag of the task, the ids of the tasks it depends on.
+func (task *Task) Dependencies() []string {
+	refs, _ := task.TagList("dep")
+	return refs
+}
+
+// DependencyGraph resolves the 'id:' and 'dep:' tags of all tasks into a DependencyGraph.
+//
+// Duplicate ids, dangling references and cycles are returned together as DependencyErrors.
+// The graph is returned in any case, ignoring dangling references and all but the first task of a duplicate id.
+func (tasklist *TaskList) DependencyGraph() (*DependencyGraph, error) {
+	graph := &DependencyGraph{
+		refs:       make(map[int]string),
+		blockers:   make(map[int][]int),
+		dependents: make(map[int][]int),
+		completed:  make(map[int]bool),
+	}
+
+	var errs DependencyErrors
+	byRef := make(map[string]int)
+	for _, task := range *tasklist {
+		graph.ids = append(graph.ids, task.Id)
+		graph.completed[task.Id] = task.Completed
+		if ref := task.DependencyId(); ref != "" {
+			if _, found := byRef[ref]; found {
+				errs = append(errs, &DependencyError{TaskId: task.Id, Ref: ref, Err: ErrDuplicateDependencyId})
+				continue
+			}
+			byRef[ref] = task.Id
+			graph.refs[task.Id] = ref
+		}
+	}
+
+	for _, task := range *tasklist {
+		for _, ref := range task.Dependencies() {
+			blocker, found := byRef[ref]
+			if !found {
+				errs = append(errs, &DependencyError{TaskId: task.Id, Ref: ref, Err: ErrDanglingDependency})
+				continue
+			}
+			if !containsInt(graph.blockers[task.Id], blocker) {
+				graph.blockers[task.Id] = append(graph.blockers[task.Id], blocker)
+				graph.dependents[blocker] = append(graph.dependents[blocker], task.Id)
+			}
+		}
+	}
+
+	errs = append(errs, graph.cycles()...)
+	if len(errs) > 0 {
+		return graph, errs
+	}
+	return graph, nil
+}
+
+// Blockers returns the ids of the tasks the given task depends on.
+func (graph *DependencyGraph) Blockers(id int) []int {
+	return graph.blockers[id]
+}
+
+// Dependents returns the ids of the tasks depending on the given task.
+func (graph *DependencyGraph) Dependents(id int) []int {
+	return graph.dependents[id]
+}
+
+// IsBlocked returns true if any of the tasks the given task depends on is not completed yet.
+func (graph *DependencyGraph) IsBlocked(id int) bool {
+	for _, blocker := range graph.blockers[id] {
+		if !graph.completed[blocker] {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActionable returns true if the given task is neither completed nor blocked.
+func (graph *DependencyGraph) IsActionable(id int) bool {
+	return !graph.completed[id] && !graph.IsBlocked(id)
+}
+
+// TopologicalOrder returns all task ids ordered so that every task comes after the tasks it depends on.
+// Apart from that, the order of the TaskList is kept.
+//
+// Returns DependencyErrors if there are cycles.
+func (graph *DependencyGraph) TopologicalOrder() ([]int, error) {
+	if errs := graph.cycles(); len(errs) > 0 {
+		return nil, errs
+	}
+
+	position := make(map[int]int, len(graph.ids))
+	waiting := make(map[int]int, len(graph.ids))
+	ready := &positionHeap{position: position}
+	for i, id := range graph.ids {
+		position[id] = i
+	}
+	for _, id := range graph.ids {
+		waiting[id] = len(graph.blockers[id])
+		if waiting[id] == 0 {
+			heap.Push(ready, id)
+		}
+	}
+
+	order := make([]int, 0, len(graph.ids))
+	for ready.Len() > 0 {
+		id := heap.Pop(ready).(int)
+		order = append(order, id)
+		for _, dependent := range graph.dependents[id] {
+			waiting[dependent]--
+			if waiting[dependent] == 0 {
+				heap.Push(ready, dependent)
+			}
+		}
+	}
+	return order, nil
+}
+
+// cycles returns an error for each dependency cycle, naming the 'id:' tags along the cycle.
+func (graph *DependencyGraph) cycles() DependencyErrors {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	var errs DependencyErrors
+	state := make(map[int]int, len(graph.ids))
+	var stack []int
+
+	var visit func(id int)
+	visit = func(id int) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, blocker := range graph.blockers[id] {
+			switch state[blocker] {
+			case unvisited:
+				visit(blocker)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != blocker {
+					start--
+				}
+				refs := make([]string, 0, len(stack)-start+1)
+				for _, cycleId := range stack[start:] {
+					refs = append(refs, graph.refs[cycleId])
+				}
+				refs = append(refs, graph.refs[blocker])
+				errs = append(errs, &DependencyError{TaskId: blocker, Ref: strings.Join(refs, " -> "), Err: ErrDependencyCycle})
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+	}
+
+	for _, id := range graph.ids {
+		if state[id] == unvisited {
+			visit(id)
+		}
+	}
+	return errs
+}
+
+// Blocked returns a new TaskList with all open tasks that depend on any other open task.
+// The original TaskList is not modified.
+func (tasklist *TaskList) Blocked() *TaskList {
+	graph, _ := tasklist.DependencyGraph()
+	return tasklist.Filter(func(t Task) bool {
+		return !t.Completed && graph.IsBlocked(t.Id)
+	})
+}
+
+// Actionable returns a new TaskList with all open tasks that do not depend on any other open task.
+// The original TaskList is not modified.
+func (tasklist *TaskList) Actionable() *TaskList {
+	graph, _ := tasklist.DependencyGraph()
+	return tasklist.Filter(func(t Task) bool {
+		return graph.IsActionable(t.Id)
+	})
+}
+
+func (tasklist *TaskList) sortByDependencies() error {
+	graph, _ := tasklist.DependencyGraph()
+	order, err := graph.TopologicalOrder()
+	if err != nil {
+		return err
+	}
+
+	position := make(map[int]int, len(order))
+	for i, id := range order {
+		position[id] = i
+	}
+	tasklist.sortBy(func(t1, t2 *Task) bool {
+		return position[t1.Id] < position[t2.Id]
+	})
+	return nil
+}
+
+// positionHeap holds task ids, popping the one with the lowest position first.
+type positionHeap struct {
+	ids      []int
+	position map[int]int
+}
+
+func (h *positionHeap) Len() int           { return len(h.ids) }
+func (h *positionHeap) Less(l, r int) bool { return h.position[h.ids[l]] < h.position[h.ids[r]] }
+func (h *positionHeap) Swap(l, r int)      { h.ids[l], h.ids[r] = h.ids[r], h.ids[l] }
+func (h *positionHeap) Push(x interface{}) { h.ids = append(h.ids, x.(int)) }
+func (h *positionHeap) Pop() interface{} {
+	id := h.ids[len(h.ids)-1]
+	h.ids = h.ids[:len(h.ids)-1]
+	return id
+}
+
+func containsInt(slice []int, i int) bool {
+	for _, element := range slice {
+		if element == i {
+			return true
+		}
+	}
+	return false
+}
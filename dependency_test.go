@@ -0,0 +1,142 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func loadDependencyTasklist(t *testing.T, text string) TaskList {
+	tasklist, err := LoadFromReader(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tasklist
+}
+
+func todos(tasklist *TaskList) string {
+	texts := make([]string, len(*tasklist))
+	for i, task := range *tasklist {
+		texts[i] = task.Todo
+	}
+	return strings.Join(texts, "|")
+}
+
+func TestTaskDependencies(t *testing.T) {
+	task, err := ParseTask("Paint walls id:paint dep:buy,,clean")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "paint"
+	testGot = task.DependencyId()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to have dependency id [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = "buy|clean"
+	testGot = strings.Join(task.Dependencies(), "|")
+	if testGot != testExpected {
+		t.Errorf("Expected Task to depend on [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	tasklist := loadDependencyTasklist(t, `Hang pictures dep:paint
+Paint walls id:paint dep:buy,clean
+x Buy paint id:buy
+Clean walls id:clean
+Call Mom
+`)
+
+	graph, err := tasklist.DependencyGraph()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "[3 4]"
+	testGot = fmt.Sprint(graph.Blockers(2))
+	if testGot != testExpected {
+		t.Errorf("Expected Task[2] to be blocked by %s, but got %s", testExpected, testGot)
+	}
+	testExpected = "[1]"
+	testGot = fmt.Sprint(graph.Dependents(2))
+	if testGot != testExpected {
+		t.Errorf("Expected Task[2] to have dependents %s, but got %s", testExpected, testGot)
+	}
+
+	for id, expected := range map[int]bool{1: true, 2: true, 3: false, 4: false, 5: false} {
+		if graph.IsBlocked(id) != expected {
+			t.Errorf("Expected Task[%d] to be blocked [%v], but it wasn't", id, expected)
+		}
+	}
+
+	testExpected = "Hang pictures|Paint walls"
+	testGot = todos(tasklist.Blocked())
+	if testGot != testExpected {
+		t.Errorf("Expected blocked tasks to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = "Clean walls|Call Mom"
+	testGot = todos(tasklist.Actionable())
+	if testGot != testExpected {
+		t.Errorf("Expected actionable tasks to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if err := tasklist.Sort(SORT_DEPENDENCIES); err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Buy paint|Clean walls|Paint walls|Hang pictures|Call Mom"
+	testGot = todos(&tasklist)
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestDependencyGraphErrors(t *testing.T) {
+	tasklist := loadDependencyTasklist(t, `A id:a dep:c
+B id:b dep:a
+C id:c dep:b
+D id:a
+E dep:missing
+F id:f dep:f
+`)
+
+	graph, err := tasklist.DependencyGraph()
+	if graph == nil || err == nil {
+		t.Fatalf("Expected DependencyGraph to return a graph and errors, but got [%v] and [%v]", graph, err)
+	}
+
+	testExpected = `task 4: duplicate dependency id: a
+task 5: dangling dependency: missing
+task 1: dependency cycle: a -> c -> b -> a
+task 6: dependency cycle: f -> f`
+	testGot = err.Error()
+	if testGot != testExpected {
+		t.Errorf("Expected errors to be [%s], but got [%s]", testExpected, testGot)
+	}
+	if !errors.Is(err, ErrDependencyCycle) || !errors.Is(err, ErrDanglingDependency) || !errors.Is(err, ErrDuplicateDependencyId) {
+		t.Errorf("Expected errors to contain all kinds of dependency errors, but got [%v]", err)
+	}
+	var dependencyError *DependencyError
+	if !errors.As(err, &dependencyError) || dependencyError.TaskId != 4 {
+		t.Errorf("Expected first DependencyError to be about Task[4], but got [%v]", dependencyError)
+	}
+
+	if graph.IsBlocked(5) {
+		t.Errorf("Expected dangling dependency not to block Task[5], but it did")
+	}
+
+	before := todos(&tasklist)
+	if err := tasklist.Sort(SORT_DEPENDENCIES); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("Expected Sort() to fail because of dependency cycles, but got [%v]", err)
+	}
+	testExpected = before
+	testGot = todos(&tasklist)
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to be unchanged after failed Sort(), but got [%s]", testGot)
+	}
+}
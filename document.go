@@ -0,0 +1,222 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// LineType identifies what kind of content a Line of a Document holds.
+type LineType int
+
+// Line types of a Document.
+const (
+	LINE_TASK    LineType = iota // Line contains a task
+	LINE_COMMENT                 // Line starts with "#", see IgnoreComments
+	LINE_BLANK                   // Line is empty or contains only whitespace
+)
+
+// Line represents a single physical line of a Document.
+type Line struct {
+	Type   LineType
+	Text   string // Raw line text, without line ending.
+	Ending string // Line ending: "\n", "\r\n" or "" for a last line without line ending.
+	Task   *Task  // Parsed task, only set for LINE_TASK.
+
+	parser *Parser // Parser of the Document the Line belongs to.
+}
+
+// Document represents a complete todo.txt file.
+//
+// Unlike a TaskList it holds every physical line, including comments and blank lines,
+// and writes all of them back in their original positions.
+type Document struct {
+	Lines        []*Line
+	Parser       *Parser // Parser the Document was loaded with, which is also used to write it back. NewParser() is used if nil.
+	GenerateUIDs bool    // Add a 'uid:' tag to tasks added to the Document, see *Task.UID().
+}
+
+// NewDocument creates a new empty Document, using the package level variable GenerateUIDs as default.
+func NewDocument() *Document {
+	return &Document{GenerateUIDs: GenerateUIDs}
+}
+
+// String returns the complete text of the Document.
+//
+// Unmodified tasks, comments and blank lines are written back exactly as they were read,
+// modified tasks are written as returned by *Task.PreservedString().
+func (doc *Document) String() string {
+	return documentWriter(doc.Parser).FormatDocument(doc)
+}
+
+// String returns the text of the Line, without line ending.
+func (line *Line) String() string {
+	return documentWriter(line.parser).formatLine(line)
+}
+
+// documentWriter returns the Writer used for a Document read by the given Parser, which always keeps the original token order.
+// NewParser() is used if the Parser is nil.
+func documentWriter(parser *Parser) *Writer {
+	if parser == nil {
+		parser = NewParser()
+	}
+	return parser.writer()
+}
+
+// Tasks returns pointers to all tasks of the Document, in the order of their lines.
+// The returned Task pointers can be used to update the tasks inside the Document.
+func (doc *Document) Tasks() []*Task {
+	var tasks []*Task
+	for _, line := range doc.Lines {
+		if line.Type == LINE_TASK {
+			tasks = append(tasks, line.Task)
+		}
+	}
+	return tasks
+}
+
+// GetTask returns a Task by given task 'id' from the Document.
+// Returns ErrTaskNotFound if Task could not be found.
+func (doc *Document) GetTask(id int) (*Task, error) {
+	for _, task := range doc.Tasks() {
+		if task.Id == id {
+			return task, nil
+		}
+	}
+	return nil, ErrTaskNotFound
+}
+
+// AddTask appends a Task as a new line at the end of the Document and takes care to set the Task.Id correctly.
+// If Document.GenerateUIDs is set, a 'uid:' tag is added to the Task too.
+// A 'uid:' tag that is already used by another Task of the Document is replaced by a new one.
+func (doc *Document) AddTask(task *Task) {
+	doc.insertLine(len(doc.Lines), task)
+}
+
+// InsertTask adds a Task to the section below the given comment header, after the last task of that section.
+// The header is matched against the comment text with or without its leading "#".
+// Returns ErrCommentNotFound if there is no such comment line.
+func (doc *Document) InsertTask(header string, task *Task) error {
+	header = strings.TrimSpace(header)
+	for i, line := range doc.Lines {
+		if line.Type != LINE_COMMENT {
+			continue
+		}
+		text := strings.TrimSpace(line.Text)
+		if text != header && strings.TrimSpace(strings.TrimPrefix(text, "#")) != header {
+			continue
+		}
+
+		// Find the end of the section, which is ended by the next comment
+		position := i + 1
+		for j := i + 1; j < len(doc.Lines) && doc.Lines[j].Type != LINE_COMMENT; j++ {
+			if doc.Lines[j].Type == LINE_TASK {
+				position = j + 1
+			}
+		}
+		doc.insertLine(position, task)
+		return nil
+	}
+	return ErrCommentNotFound
+}
+
+// RemoveTaskById removes the line of the Task with given Task 'id' from the Document.
+// Returns ErrTaskNotFound if no Task was removed.
+func (doc *Document) RemoveTaskById(id int) error {
+	for i, line := range doc.Lines {
+		if line.Type == LINE_TASK && line.Task.Id == id {
+			doc.Lines = append(doc.Lines[:i], doc.Lines[i+1:]...)
+			return nil
+		}
+	}
+	return ErrTaskNotFound
+}
+
+// insertLine inserts a new task line at the given position, using the line ending of the Document.
+func (doc *Document) insertLine(position int, task *Task) {
+	if doc.GenerateUIDs || task.HasUID() {
+		task.ensureUID(doc.Tasks())
+	}
+	task.Id = 0
+	for _, t := range doc.Tasks() {
+		if t.Id > task.Id {
+			task.Id = t.Id
+		}
+	}
+	task.Id += 1
+
+	ending := "\n"
+	if len(doc.Lines) > 0 && doc.Lines[0].Ending != "" {
+		ending = doc.Lines[0].Ending
+	}
+	// The previous last line needs a line ending now
+	if position == len(doc.Lines) && position > 0 && doc.Lines[position-1].Ending == "" {
+		doc.Lines[position-1].Ending = ending
+	}
+
+	line := &Line{Type: LINE_TASK, Text: task.String(), Ending: ending, Task: task, parser: doc.Parser}
+	doc.Lines = append(doc.Lines, nil)
+	copy(doc.Lines[position+1:], doc.Lines[position:])
+	doc.Lines[position] = line
+}
+
+// LoadFromFile loads a Document from *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdin.
+//
+// The Parser of the Document is used if set, otherwise NewParser().
+//
+// Note: This will clear the current Document and overwrite it's contents with whatever is in *os.File.
+func (doc *Document) LoadFromFile(file *os.File) error {
+	parser := doc.Parser
+	if parser == nil {
+		parser = NewParser()
+	}
+	return parser.loadDocument(doc, file)
+}
+
+// WriteToFile writes a Document to *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdout.
+func (doc *Document) WriteToFile(file *os.File) error {
+	return documentWriter(doc.Parser).WriteDocumentToFile(doc, file)
+}
+
+// LoadFromFilename loads a Document from a file (most likely called "todo.txt").
+//
+// Note: This will clear the current Document and overwrite it's contents with whatever is in the file.
+func (doc *Document) LoadFromFilename(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return doc.LoadFromFile(file)
+}
+
+// WriteToFilename writes a Document to the specified file (most likely called "todo.txt").
+func (doc *Document) WriteToFilename(filename string) error {
+	return documentWriter(doc.Parser).WriteDocumentToFilename(doc, filename)
+}
+
+// LoadDocumentFromFile loads and returns a Document from *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdin.
+func LoadDocumentFromFile(file *os.File) (*Document, error) {
+	return NewParser().LoadDocumentFromFile(file)
+}
+
+// LoadDocumentFromReader loads and returns a Document from any io.Reader.
+func LoadDocumentFromReader(r io.Reader) (*Document, error) {
+	return NewParser().LoadDocumentFromReader(r)
+}
+
+// LoadDocumentFromFilename loads and returns a Document from a file (most likely called "todo.txt").
+func LoadDocumentFromFilename(filename string) (*Document, error) {
+	return NewParser().LoadDocumentFromFilename(filename)
+}
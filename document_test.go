@@ -0,0 +1,152 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+var (
+	testInputDocument = "testdata/document_todo.txt"
+)
+
+func TestLoadDocumentFromFilename(t *testing.T) {
+	doc, err := LoadDocumentFromFilename(testInputDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = 11
+	testGot = len(doc.Lines)
+	if testGot != testExpected {
+		t.Errorf("Expected Document to contain %d lines, but got %d", testExpected, testGot)
+	}
+
+	testExpected = 5
+	testGot = len(doc.Tasks())
+	if testGot != testExpected {
+		t.Errorf("Expected Document to contain %d tasks, but got %d", testExpected, testGot)
+	}
+
+	for i, lineType := range []LineType{LINE_COMMENT, LINE_TASK, LINE_TASK, LINE_BLANK, LINE_COMMENT, LINE_TASK} {
+		testExpected = lineType
+		testGot = doc.Lines[i].Type
+		if testGot != testExpected {
+			t.Errorf("Expected Line[%d] to be of type %d, but got %d", i+1, testExpected, testGot)
+		}
+	}
+
+	task, err := doc.GetTask(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Pick up milk"
+	testGot = task.Todo
+	if testGot != testExpected {
+		t.Errorf("Expected Task[3] to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if _, err := LoadDocumentFromFilename("some_file_that_does_not_exists.txt"); err == nil {
+		t.Errorf("Expected LoadDocumentFromFilename to fail")
+	}
+}
+
+func TestDocumentWriteFilename(t *testing.T) {
+	doc, err := LoadDocumentFromFilename(testInputDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Remove(testOutput)
+	if err := doc.WriteToFilename(testOutput); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(testInputDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = string(data)
+	data, err = ioutil.ReadFile(testOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testGot = string(data)
+	if testGot != testExpected {
+		t.Errorf("Expected Document to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestDocumentUpdateTask(t *testing.T) {
+	doc, err := LoadDocumentFromFilename(testInputDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := doc.GetTask(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task.Reopen()
+
+	testExpected = "# Work\n(A) 2014-01-01 Call Mom @Phone +Family  \n(B) Outline chapter 5 +Novel @Computer due:2014-02-17\n\n" +
+		"# Home\n\tPick up milk @GroceryStore\nPlan backyard herb garden @Home\n\n\n" +
+		"## Someday\nResearch self-publishing services +Novel"
+	testGot = doc.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Document to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestDocumentInsertTask(t *testing.T) {
+	doc, err := LoadDocumentFromFilename(testInputDocument)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := ParseTask("Water the plants @Home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.InsertTask("Home", task); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.InsertTask("# Someday", &Task{Todo: "Learn piano"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.InsertTask("Garden", &Task{Todo: "Mow the lawn"}); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Expected comment not to be found, but got [%v]", err)
+	}
+	doc.AddTask(&Task{Todo: "Buy a new phone", Contexts: []string{"Phone"}})
+
+	testExpected = "# Work\n(A) 2014-01-01 Call Mom @Phone +Family  \n(B) Outline chapter 5 +Novel @Computer due:2014-02-17\n\n" +
+		"# Home\n\tPick up milk @GroceryStore\nx 2014-01-03 Plan backyard herb garden @Home\nWater the plants @Home\n\n\n" +
+		"## Someday\nResearch self-publishing services +Novel\nLearn piano\nBuy a new phone @Phone\n"
+	testGot = doc.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Document to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = 6
+	testGot = task.Id
+	if testGot != testExpected {
+		t.Errorf("Expected inserted Task to have Id [%d], but got [%d]", testExpected, testGot)
+	}
+
+	if err := doc.RemoveTaskById(task.Id); err != nil {
+		t.Error(err)
+	}
+	if err := doc.RemoveTaskById(task.Id); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected no Task to be found for removal, but got [%v]", err)
+	}
+	testExpected = 13
+	testGot = len(doc.Lines)
+	if testGot != testExpected {
+		t.Errorf("Expected Document to contain %d lines, but got %d", testExpected, testGot)
+	}
+}
@@ -0,0 +1,167 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrTaskNotFound is returned if a Task could not be found in a TaskList.
+	ErrTaskNotFound = errors.New("task not found")
+
+	// ErrUnrecognizedSortOption is returned by TaskList.Sort() for unknown SORT_* flags.
+	ErrUnrecognizedSortOption = errors.New("unrecognized sort option")
+
+	// ErrInvalidRecurrence is returned if the 'rec:' tag of a Task is missing or can not be parsed.
+	ErrInvalidRecurrence = errors.New("invalid recurrence")
+
+	// ErrDanglingDependency is returned if a 'dep:' tag references an 'id:' that no task has.
+	ErrDanglingDependency = errors.New("dangling dependency")
+
+	// ErrDuplicateDependencyId is returned if several tasks have the same 'id:' tag.
+	ErrDuplicateDependencyId = errors.New("duplicate dependency id")
+
+	// ErrDependencyCycle is returned if tasks depend on each other in a cycle.
+	ErrDependencyCycle = errors.New("dependency cycle")
+
+	// ErrTagNotFound is returned by the typed tag accessors if a Task has no tag with the requested key.
+	ErrTagNotFound = errors.New("tag not found")
+
+	// ErrInvalidTagKey is returned if a key can not be used for an additional tag.
+	ErrInvalidTagKey = errors.New("invalid tag key")
+
+	// ErrInvalidTagValue is returned if a value can not be written as the value of an additional tag.
+	ErrInvalidTagValue = errors.New("invalid tag value")
+
+	// ErrInvalidPriority is returned if a priority is not a single uppercase letter.
+	ErrInvalidPriority = errors.New("invalid priority")
+
+	// ErrInvalidContext is returned if a context can not be written as part of a Task.
+	ErrInvalidContext = errors.New("invalid context")
+
+	// ErrInvalidProject is returned if a project can not be written as part of a Task.
+	ErrInvalidProject = errors.New("invalid project")
+
+	// ErrContextNotFound is returned if a Task does not have the context to be removed.
+	ErrContextNotFound = errors.New("context not found")
+
+	// ErrProjectNotFound is returned if a Task does not have the project to be removed.
+	ErrProjectNotFound = errors.New("project not found")
+
+	// ErrInvalidTodo is returned if a todo text contains line breaks.
+	ErrInvalidTodo = errors.New("invalid todo text")
+
+	// ErrTimerRunning is returned if a timer is started on a Task whose timer is already running.
+	ErrTimerRunning = errors.New("timer already running")
+
+	// ErrTimerNotRunning is returned if a timer is stopped on a Task whose timer is not running.
+	ErrTimerNotRunning = errors.New("timer not running")
+
+	// ErrUnparsedTask is returned if an unparsed Task is changed, see Task.Unparsed.
+	ErrUnparsedTask = errors.New("task is unparsed")
+
+	// ErrNoteNotFound is returned if a Task has no 'note:' tag, or its note does not exist.
+	ErrNoteNotFound = errors.New("note not found")
+
+	// ErrNoteExists is returned if a note is created for a Task that already has a 'note:' tag.
+	ErrNoteExists = errors.New("note already exists")
+
+	// ErrInvalidNoteId is returned if the value of a 'note:' tag can not be used as the file name of a note.
+	ErrInvalidNoteId = errors.New("invalid note id")
+
+	// ErrCommentNotFound is returned if a comment line could not be found in a Document.
+	ErrCommentNotFound = errors.New("comment not found")
+)
+
+// ParseError describes a problem with parsing a single line into a Task.
+// It wraps the underlying cause, which usually is a *time.ParseError.
+// For tags with a registered TagType, it is the error of reading the value as that type, see RegisterTagType().
+type ParseError struct {
+	Line   int    // Line number of the offending line, starting at 1. Is 0 if the task was not read from a file.
+	Column int    // Column of the offending token, starting at 1.
+	Text   string // Text of the offending line.
+	Field  string // Name of the field that failed to parse: "completed", "created", "due", "threshold" or the key of a typed tag.
+	Err    error  // Underlying cause.
+}
+
+// Error returns a description of the error, including its position.
+func (err *ParseError) Error() string {
+	var text string
+	if err.Line > 0 {
+		text = fmt.Sprintf("line %d, ", err.Line)
+	}
+	switch err.Field {
+	case "completed", "created", "due", "threshold":
+		return text + fmt.Sprintf("column %d: invalid %s date: %v", err.Column, err.Field, err.Err)
+	}
+	return text + fmt.Sprintf("column %d: invalid %s tag: %v", err.Column, err.Field, err.Err)
+}
+
+// Unwrap returns the underlying cause of the error.
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// ParseErrors collects all ParseError's found while loading a TaskList.
+type ParseErrors []*ParseError
+
+// Error returns the descriptions of all errors, one per line.
+func (errs ParseErrors) Error() string {
+	texts := make([]string, len(errs))
+	for i, err := range errs {
+		texts[i] = err.Error()
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Unwrap returns all contained errors, so that errors.Is and errors.As can inspect each of them.
+func (errs ParseErrors) Unwrap() []error {
+	list := make([]error, len(errs))
+	for i, err := range errs {
+		list[i] = err
+	}
+	return list
+}
+
+// DependencyError describes a problem with the 'id:' and 'dep:' tags of a task.
+type DependencyError struct {
+	TaskId int    // Id of the offending task.
+	Ref    string // Offending 'id:' or 'dep:' value, or the 'id:' values along a cycle: 'a -> b -> a'
+	Err    error  // ErrDanglingDependency, ErrDuplicateDependencyId or ErrDependencyCycle.
+}
+
+// Error returns a description of the error, including the task id.
+func (err *DependencyError) Error() string {
+	return fmt.Sprintf("task %d: %v: %s", err.TaskId, err.Err, err.Ref)
+}
+
+// Unwrap returns the kind of the error.
+func (err *DependencyError) Unwrap() error {
+	return err.Err
+}
+
+// DependencyErrors collects all DependencyError's found in a TaskList.
+type DependencyErrors []*DependencyError
+
+// Error returns the descriptions of all errors, one per line.
+func (errs DependencyErrors) Error() string {
+	texts := make([]string, len(errs))
+	for i, err := range errs {
+		texts[i] = err.Error()
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Unwrap returns all contained errors, so that errors.Is and errors.As can inspect each of them.
+func (errs DependencyErrors) Unwrap() []error {
+	list := make([]error, len(errs))
+	for i, err := range errs {
+		list[i] = err
+	}
+	return list
+}
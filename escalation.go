@@ -0,0 +1,180 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// EscalationCondition decides if an EscalationRule applies to a task, at the current time of the given Clock.
+//
+// Conditions that do not depend on the current time are Predicates, see EscalateIf().
+type EscalationCondition func(task *Task, clock Clock) bool
+
+// EscalateIf matches tasks that fulfill the given Predicate, for example EscalateIf(HasTag("urgent", "yes")).
+func EscalateIf(predicate Predicate) EscalationCondition {
+	return func(task *Task, clock Clock) bool {
+		return predicate.Match(*task)
+	}
+}
+
+// EscalateIfDueWithin matches tasks that are due within the given number of calendar days, or overdue.
+// EscalateIfDueWithin(0) matches tasks due today.
+func EscalateIfDueWithin(days int) EscalationCondition {
+	return func(task *Task, clock Clock) bool {
+		if !task.HasDueDate() {
+			return false
+		}
+		return !dateOf(wallClock(task.DueDate)).After(dateOf(task.now(clock)).AddDate(0, 0, days))
+	}
+}
+
+// EscalateIfOverdue matches tasks that are overdue, see *Task.IsOverdue().
+func EscalateIfOverdue() EscalationCondition {
+	return func(task *Task, clock Clock) bool {
+		return task.IsOverdueWith(clock)
+	}
+}
+
+// EscalateIfOlderThan matches tasks that were created more than the given number of calendar days ago.
+// Tasks without created date do not match.
+func EscalateIfOlderThan(days int) EscalationCondition {
+	return func(task *Task, clock Clock) bool {
+		if !task.HasCreatedDate() {
+			return false
+		}
+		return dateOf(wallClock(task.CreatedDate)).AddDate(0, 0, days).Before(dateOf(task.now(clock)))
+	}
+}
+
+// EscalationRule changes the priority of open tasks from one priority to another, when its condition matches.
+//
+// For example "C becomes B within 3 days of due, B becomes A when overdue":
+//
+//	rules := []EscalationRule{
+//		{From: "C", To: "B", When: EscalateIfDueWithin(3)},
+//		{From: "B", To: "A", When: EscalateIfOverdue()},
+//	}
+type EscalationRule struct {
+	Name string              // Name of the rule, used in reports. "(From) -> (To)" is used if empty.
+	From string              // Priority the rule applies to, "" for tasks without priority.
+	To   string              // New priority of the task, "" to remove the priority. Rules with an invalid priority are ignored.
+	When EscalationCondition // Condition of the rule. The rule applies to all tasks with the From priority if nil.
+}
+
+// String returns the name of the rule.
+func (rule EscalationRule) String() string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("%s -> %s", priorityName(rule.From), priorityName(rule.To))
+}
+
+// matches returns true if the rule applies to the task.
+func (rule EscalationRule) matches(task *Task, clock Clock) bool {
+	return task.Priority == rule.From && (rule.To == "" || isPriorityLetter(rule.To)) && (rule.When == nil || rule.When(task, clock))
+}
+
+// Escalation describes the priority change of a single task.
+type Escalation struct {
+	TaskId int
+	Todo   string
+	From   string           // Priority before, "" if the task had none.
+	To     string           // Priority after, "" if the task has none anymore.
+	Rules  []EscalationRule // Rules applied to the task, in order.
+}
+
+// String returns a description of the escalation: 'Task 3: (C) -> (A) Call Dad [(C) -> (B), (B) -> (A)]'
+func (escalation Escalation) String() string {
+	rules := make([]string, len(escalation.Rules))
+	for i, rule := range escalation.Rules {
+		rules[i] = rule.String()
+	}
+	return fmt.Sprintf("Task %d: %s -> %s %s [%s]", escalation.TaskId,
+		priorityName(escalation.From), priorityName(escalation.To), escalation.Todo, strings.Join(rules, ", "))
+}
+
+// EscalationReport lists all priority changes of an escalation run.
+type EscalationReport []Escalation
+
+// String returns the descriptions of all escalations, one per line.
+func (report EscalationReport) String() string {
+	var text strings.Builder
+	for _, escalation := range report {
+		text.WriteString(escalation.String())
+		text.WriteByte('\n')
+	}
+	return text.String()
+}
+
+// Escalator applies EscalationRules to the open tasks of a TaskList.
+type Escalator struct {
+	Rules  []EscalationRule // Rules are tried in order. After a rule changed a task, all rules are tried again for its new priority.
+	Clock  Clock            // Clock used by the rule conditions. The Clock of each task is used if nil, see Parser.Clock.
+	DryRun bool             // Only report the changes, without modifying any task.
+}
+
+// NewEscalator creates a new Escalator with the given rules.
+func NewEscalator(rules ...EscalationRule) *Escalator {
+	return &Escalator{Rules: rules}
+}
+
+// Escalate changes the priorities of all open tasks according to the rules of the Escalator, and reports what changed.
+// Completed, hidden and unparsed tasks are left alone.
+//
+// Rules are applied repeatedly, so that a task can rise several levels at once: with the rules of the example of
+// EscalationRule, an overdue task goes from C to A. A task is not changed back to a priority it already had during the same run.
+func (escalator *Escalator) Escalate(tasklist *TaskList) EscalationReport {
+	var report EscalationReport
+	for i := range *tasklist {
+		task := &(*tasklist)[i]
+		if task.Completed || task.Hidden || task.Unparsed {
+			continue
+		}
+		if escalation, changed := escalator.escalate(task); changed {
+			report = append(report, escalation)
+			if !escalator.DryRun {
+				task.SetPriority(escalation.To)
+			}
+		}
+	}
+	return report
+}
+
+// escalate returns the escalation of a single task, without modifying it.
+func (escalator *Escalator) escalate(task *Task) (Escalation, bool) {
+	escalation := Escalation{TaskId: task.Id, Todo: task.Todo, From: task.Priority, To: task.Priority}
+	candidate := *task
+	seen := map[string]bool{task.Priority: true}
+	for applied := true; applied; {
+		applied = false
+		for _, rule := range escalator.Rules {
+			if !seen[rule.To] && rule.matches(&candidate, escalator.Clock) {
+				seen[rule.To] = true
+				candidate.Priority = rule.To
+				escalation.To = rule.To
+				escalation.Rules = append(escalation.Rules, rule)
+				applied = true
+				break
+			}
+		}
+	}
+	return escalation, len(escalation.Rules) > 0
+}
+
+// Escalate changes the priorities of all open tasks according to the given rules, and reports what changed.
+// See *Escalator.Escalate() for further information.
+func (tasklist *TaskList) Escalate(rules ...EscalationRule) EscalationReport {
+	return NewEscalator(rules...).Escalate(tasklist)
+}
+
+// priorityName returns the priority as written in a task, '(A)', or 'none'.
+func priorityName(priority string) string {
+	if priority == "" {
+		return "none"
+	}
+	return "(" + priority + ")"
+}
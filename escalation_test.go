@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+var testInputEscalation = `(C) Call Dad due:2014-01-20
+(C) Pay rent due:2014-01-15
+(C) Renew passport due:2014-01-10
+(B) Write report due:2014-01-11
+2014-01-01 Clean garage
+2013-12-01 Fix the bike
+x (C) Call Mom due:2014-01-10
+(C) Plan vacation urgent:yes
+`
+
+func testEscalator() *Escalator {
+	escalator := NewEscalator(
+		EscalationRule{From: "C", To: "B", When: EscalateIfDueWithin(3)},
+		EscalationRule{From: "B", To: "A", When: EscalateIfOverdue()},
+		EscalationRule{Name: "stale", From: "", To: "C", When: EscalateIfOlderThan(30)},
+		EscalationRule{From: "C", To: "A", When: EscalateIf(HasTag("urgent", "yes"))},
+	)
+	escalator.Clock = todotxttest.FixedClock(time.Date(2014, 1, 12, 9, 0, 0, 0, Location))
+	return escalator
+}
+
+func TestEscalatorEscalate(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputEscalation))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := testEscalator().Escalate(&tasklist)
+	testExpected = `Task 2: (C) -> (B) Pay rent [(C) -> (B)]
+Task 3: (C) -> (A) Renew passport [(C) -> (B), (B) -> (A)]
+Task 4: (B) -> (A) Write report [(B) -> (A)]
+Task 6: none -> (C) Fix the bike [stale]
+Task 8: (C) -> (A) Plan vacation [(C) -> (A)]
+`
+	testGot = report.String()
+	if testGot != testExpected {
+		t.Errorf("Expected EscalationReport to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "C B A A  C C A"
+	testGot = ""
+	for i, task := range tasklist {
+		if i > 0 {
+			testGot = testGot.(string) + " "
+		}
+		testGot = testGot.(string) + task.Priority
+	}
+	if testGot != testExpected {
+		t.Errorf("Expected priorities to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	// Running it again does not change anything
+	if report := testEscalator().Escalate(&tasklist); len(report) != 0 {
+		t.Errorf("Expected no further escalations, but got [%s]", report)
+	}
+}
+
+func TestEscalatorDryRun(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputEscalation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := tasklist.String()
+
+	escalator := testEscalator()
+	escalator.DryRun = true
+	testExpected = 5
+	testGot = len(escalator.Escalate(&tasklist))
+	if testGot != testExpected {
+		t.Errorf("Expected %d escalations, but got %d", testExpected, testGot)
+	}
+	testExpected = original
+	testGot = tasklist.String()
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to be unchanged [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskListEscalateCycle(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("(A) Call Dad\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := tasklist.Escalate(EscalationRule{From: "A", To: "B"}, EscalationRule{From: "B", To: "A"})
+	testExpected = "Task 1: (A) -> (B) Call Dad [(A) -> (B)]\n"
+	testGot = report.String()
+	if testGot != testExpected {
+		t.Errorf("Expected EscalationReport to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
@@ -0,0 +1,222 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"strings"
+)
+
+// ParentId returns the value of the 'p:' tag of the task, the 'id:' tag of its parent task.
+//
+// For example, 'Buy paint p:renovate' is a subtask of 'Renovate kitchen id:renovate'.
+func (task *Task) ParentId() string {
+	return task.AdditionalTags["p"]
+}
+
+// hierarchy holds the parent/child relations of a TaskList, as indexes into it.
+type hierarchy struct {
+	parent   map[int]int   // Index of a task -> index of its parent
+	children map[int][]int // Index of a task -> indexes of its children, in TaskList order
+}
+
+func (tasklist *TaskList) hierarchy() *hierarchy {
+	h := &hierarchy{
+		parent:   make(map[int]int),
+		children: make(map[int][]int),
+	}
+
+	byRef := make(map[string]int)
+	for i, task := range *tasklist {
+		if ref := task.DependencyId(); ref != "" {
+			if _, found := byRef[ref]; !found {
+				byRef[ref] = i
+			}
+		}
+	}
+	for i, task := range *tasklist {
+		if parent, found := byRef[task.ParentId()]; found && parent != i {
+			h.parent[i] = parent
+			h.children[parent] = append(h.children[parent], i)
+		}
+	}
+	return h
+}
+
+// descendants returns the indexes of all descendants of a task, depth first.
+// Parent cycles are cut off, each task is returned only once.
+func (h *hierarchy) descendants(index int) []int {
+	var indexes []int
+	seen := map[int]bool{index: true}
+	var walk func(int)
+	walk = func(parent int) {
+		for _, child := range h.children[parent] {
+			if !seen[child] {
+				seen[child] = true
+				indexes = append(indexes, child)
+				walk(child)
+			}
+		}
+	}
+	walk(index)
+	return indexes
+}
+
+// indexOf returns the index of the task with the given id.
+func (tasklist *TaskList) indexOf(id int) (int, error) {
+	for i, task := range *tasklist {
+		if task.Id == id {
+			return i, nil
+		}
+	}
+	return -1, ErrTaskNotFound
+}
+
+// subset returns a new TaskList with copies of the tasks at the given indexes.
+func (tasklist *TaskList) subset(indexes []int) *TaskList {
+	newList := make(TaskList, 0, len(indexes))
+	for _, i := range indexes {
+		newList = append(newList, (*tasklist)[i])
+	}
+	return &newList
+}
+
+// Children returns a new TaskList with the direct subtasks of the task with the given id.
+// The original TaskList is not modified.
+//
+// Returns ErrTaskNotFound if there is no such task.
+func (tasklist *TaskList) Children(id int) (*TaskList, error) {
+	index, err := tasklist.indexOf(id)
+	if err != nil {
+		return nil, err
+	}
+	return tasklist.subset(tasklist.hierarchy().children[index]), nil
+}
+
+// Ancestors returns a new TaskList with the parent of the task with the given id, the parent's parent and so on.
+// The original TaskList is not modified.
+//
+// Returns ErrTaskNotFound if there is no such task.
+func (tasklist *TaskList) Ancestors(id int) (*TaskList, error) {
+	index, err := tasklist.indexOf(id)
+	if err != nil {
+		return nil, err
+	}
+
+	h := tasklist.hierarchy()
+	var indexes []int
+	seen := map[int]bool{index: true}
+	for parent, found := h.parent[index]; found && !seen[parent]; parent, found = h.parent[parent] {
+		seen[parent] = true
+		indexes = append(indexes, parent)
+	}
+	return tasklist.subset(indexes), nil
+}
+
+// Subtree returns a new TaskList with the task with the given id, followed by all of its subtasks, depth first.
+// The original TaskList is not modified.
+//
+// Returns ErrTaskNotFound if there is no such task.
+func (tasklist *TaskList) Subtree(id int) (*TaskList, error) {
+	index, err := tasklist.indexOf(id)
+	if err != nil {
+		return nil, err
+	}
+	return tasklist.subset(append([]int{index}, tasklist.hierarchy().descendants(index)...)), nil
+}
+
+// CompletionPercentage returns the percentage (0 to 100) of completed subtasks of the task with the given id,
+// counting all levels below it. A task without subtasks is either 0 or 100 percent complete.
+//
+// Returns ErrTaskNotFound if there is no such task.
+func (tasklist *TaskList) CompletionPercentage(id int) (float64, error) {
+	index, err := tasklist.indexOf(id)
+	if err != nil {
+		return 0, err
+	}
+
+	descendants := tasklist.hierarchy().descendants(index)
+	if len(descendants) == 0 {
+		if (*tasklist)[index].Completed {
+			return 100, nil
+		}
+		return 0, nil
+	}
+	completed := 0
+	for _, i := range descendants {
+		if (*tasklist)[i].Completed {
+			completed++
+		}
+	}
+	return 100 * float64(completed) / float64(len(descendants)), nil
+}
+
+// RollUp completes all open parent tasks whose subtasks are all completed, see *Task.Complete().
+// This is repeated upwards, so that completing the last subtask can also complete grandparents.
+//
+// Returns the ids of the completed parent tasks.
+func (tasklist *TaskList) RollUp() []int {
+	return tasklist.RollUpWith(nil)
+}
+
+// RollUpWith works like RollUp(), using the given Clock for the completed dates.
+func (tasklist *TaskList) RollUpWith(clock Clock) []int {
+	h := tasklist.hierarchy()
+
+	var ids []int
+	for changed := true; changed; {
+		changed = false
+		for parent := range *tasklist {
+			children := h.children[parent]
+			task := &(*tasklist)[parent]
+			if len(children) == 0 || task.Completed {
+				continue
+			}
+			done := true
+			for _, child := range children {
+				done = done && (*tasklist)[child].Completed
+			}
+			if done {
+				task.CompleteWith(clock)
+				ids = append(ids, task.Id)
+				changed = true
+			}
+		}
+	}
+	return ids
+}
+
+// Outline returns the TaskList as an indented outline, with each subtask below its parent task.
+// Each level is indented by two spaces, tasks are written as by *Task.String().
+func (tasklist *TaskList) Outline() string {
+	h := tasklist.hierarchy()
+
+	var text strings.Builder
+	seen := make(map[int]bool)
+	var write func(index, depth int)
+	write = func(index, depth int) {
+		seen[index] = true
+		text.WriteString(strings.Repeat("  ", depth))
+		text.WriteString((*tasklist)[index].String())
+		text.WriteByte('\n')
+		for _, child := range h.children[index] {
+			if !seen[child] {
+				write(child, depth+1)
+			}
+		}
+	}
+
+	for i := range *tasklist {
+		if _, found := h.parent[i]; !found {
+			write(i, 0)
+		}
+	}
+	// Tasks whose parents form a cycle have no root, they are written at the top level
+	for i := range *tasklist {
+		if !seen[i] {
+			write(i, 0)
+		}
+	}
+	return text.String()
+}
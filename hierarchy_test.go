@@ -0,0 +1,128 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+var testInputHierarchy = `Renovate kitchen id:kitchen
+Buy paint p:kitchen
+Paint walls id:paint p:kitchen
+x Clean walls p:paint
+Tape edges p:paint
+Call Mom
+Orphan p:missing
+`
+
+func TestTaskListHierarchy(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputHierarchy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	children, err := tasklist.Children(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Buy paint|Paint walls"
+	testGot = todos(children)
+	if testGot != testExpected {
+		t.Errorf("Expected children to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	ancestors, err := tasklist.Ancestors(5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Paint walls|Renovate kitchen"
+	testGot = todos(ancestors)
+	if testGot != testExpected {
+		t.Errorf("Expected ancestors to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	subtree, err := tasklist.Subtree(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Renovate kitchen|Buy paint|Paint walls|Clean walls|Tape edges"
+	testGot = todos(subtree)
+	if testGot != testExpected {
+		t.Errorf("Expected subtree to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	for id, expected := range map[int]float64{1: 25, 3: 50, 4: 100, 6: 0} {
+		percentage, err := tasklist.CompletionPercentage(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testExpected = expected
+		testGot = percentage
+		if testGot != testExpected {
+			t.Errorf("Expected Task[%d] to be %v%% complete, but got %v%%", id, testExpected, testGot)
+		}
+	}
+
+	if _, err := tasklist.Subtree(99); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, but got [%v]", err)
+	}
+}
+
+func TestTaskListRollUp(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputHierarchy))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "[]"
+	testGot = fmt.Sprint(tasklist.RollUp())
+	if testGot != testExpected {
+		t.Errorf("Expected no parent to be completed, but got %s", testGot)
+	}
+
+	tasklist[1].Complete()
+	tasklist[4].Complete()
+	testExpected = "[3 1]"
+	testGot = fmt.Sprint(tasklist.RollUpWith(todotxttest.FixedClock(time.Date(2014, 1, 12, 9, 0, 0, 0, time.UTC))))
+	if testGot != testExpected {
+		t.Errorf("Expected parents %s to be completed, but got %s", testExpected, testGot)
+	}
+	if !tasklist[0].Completed || !tasklist[2].Completed {
+		t.Errorf("Expected Task[1] and Task[3] to be completed, but they weren't")
+	}
+	testExpected = "2014-01-12"
+	testGot = tasklist[0].CompletedDate.Format(DateLayout)
+	if testGot != testExpected {
+		t.Errorf("Expected Task[1] to be completed on [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskListOutline(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputHierarchy + "Chicken id:chicken p:egg\nEgg id:egg p:chicken\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = `Renovate kitchen id:kitchen
+  Buy paint p:kitchen
+  Paint walls id:paint p:kitchen
+    x Clean walls p:paint
+    Tape edges p:paint
+Call Mom
+Orphan p:missing
+Chicken id:chicken p:egg
+  Egg id:egg p:chicken
+`
+	testGot = tasklist.Outline()
+	if testGot != testExpected {
+		t.Errorf("Expected outline to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
@@ -0,0 +1,160 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+// NewUID returns a new random value for a 'uid:' tag, 12 hexadecimal digits.
+func NewUID() string {
+	bytes := make([]byte, 6)
+	if _, err := rand.Read(bytes); err != nil {
+		panic(err) // crypto/rand does not fail on supported platforms
+	}
+	return hex.EncodeToString(bytes)
+}
+
+// HasUID returns true if the task has a 'uid:' tag.
+func (task *Task) HasUID() bool {
+	_, found := task.AdditionalTags["uid"]
+	return found
+}
+
+// UID returns the stable identity of the task.
+// This is the value of its 'uid:' tag, or the fingerprint of its content if it has none, see *Task.Fingerprint().
+//
+// Unlike Task.Id, it does not change when lines are inserted, removed or reordered.
+func (task *Task) UID() string {
+	if uid, found := task.AdditionalTags["uid"]; found {
+		return uid
+	}
+	return task.Fingerprint()
+}
+
+// Fingerprint returns an identity derived from the todo text, contexts, projects and created date of the task, 12 hexadecimal digits.
+//
+// It stays the same when the task is completed, reprioritized or its tags change, but not when its text is edited.
+// Tasks with the same text, contexts, projects and created date have the same fingerprint.
+func (task *Task) Fingerprint() string {
+	hash := sha1.New()
+	hash.Write([]byte(task.Todo))
+	for _, context := range sortedStrings(task.Contexts) {
+		hash.Write([]byte("\x00@" + context))
+	}
+	for _, project := range sortedStrings(task.Projects) {
+		hash.Write([]byte("\x00+" + project))
+	}
+	if task.HasCreatedDate() {
+		hash.Write([]byte("\x00" + task.CreatedDate.Format("2006-01-02")))
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// ensureUID adds a new 'uid:' tag to the task if it has none yet, or if its 'uid:' tag is already used by another one of the given tasks.
+// New values are never used by one of the given tasks either.
+func (task *Task) ensureUID(tasks []*Task) {
+	if uid, found := task.AdditionalTags["uid"]; found {
+		duplicate := false
+		for _, other := range tasks {
+			if other != task && other.AdditionalTags["uid"] == uid {
+				duplicate = true
+			}
+		}
+		if !duplicate {
+			return
+		}
+	}
+	uid := NewUID()
+	for taskWithUID(tasks, uid) != nil {
+		uid = NewUID()
+	}
+	task.setTag("uid", uid)
+}
+
+// taskWithUID returns the task with the given stable identity, see *Task.UID().
+// Tasks with a 'uid:' tag take precedence over fingerprints, otherwise the first matching task is returned.
+func taskWithUID(tasks []*Task, uid string) *Task {
+	for _, task := range tasks {
+		if value, found := task.AdditionalTags["uid"]; found && value == uid {
+			return task
+		}
+	}
+	for _, task := range tasks {
+		if !task.HasUID() && task.Fingerprint() == uid {
+			return task
+		}
+	}
+	return nil
+}
+
+// pointers returns pointers to all tasks of the TaskList.
+func (tasklist *TaskList) pointers() []*Task {
+	tasks := make([]*Task, len(*tasklist))
+	for i := range *tasklist {
+		tasks[i] = &(*tasklist)[i]
+	}
+	return tasks
+}
+
+// GetTaskByUID returns a Task by its stable identity, see *Task.UID(). The returned Task pointer can be used to update the Task inside the TaskList.
+// Returns ErrTaskNotFound if Task could not be found.
+func (tasklist *TaskList) GetTaskByUID(uid string) (*Task, error) {
+	if task := taskWithUID(tasklist.pointers(), uid); task != nil {
+		return task, nil
+	}
+	return nil, ErrTaskNotFound
+}
+
+// AssignUIDs adds a new 'uid:' tag to all tasks without one, except unparsed tasks, and returns the number of tasks changed.
+func (tasklist *TaskList) AssignUIDs() int {
+	tasks := tasklist.pointers()
+	count := 0
+	for _, task := range tasks {
+		if !task.HasUID() && !task.Unparsed {
+			task.ensureUID(tasks)
+			count++
+		}
+	}
+	return count
+}
+
+// Renumber sets the Task.Id of all tasks to their position in the TaskList, starting at 1, as todo.sh numbers them.
+func (tasklist *TaskList) Renumber() {
+	for i := range *tasklist {
+		(*tasklist)[i].Id = i + 1
+	}
+}
+
+// GetTaskByUID returns a Task by its stable identity from the Document, see *Task.UID().
+// Returns ErrTaskNotFound if Task could not be found.
+func (doc *Document) GetTaskByUID(uid string) (*Task, error) {
+	if task := taskWithUID(doc.Tasks(), uid); task != nil {
+		return task, nil
+	}
+	return nil, ErrTaskNotFound
+}
+
+// AssignUIDs adds a new 'uid:' tag to all tasks of the Document without one, except unparsed tasks, and returns the number of tasks changed.
+func (doc *Document) AssignUIDs() int {
+	tasks := doc.Tasks()
+	count := 0
+	for _, task := range tasks {
+		if !task.HasUID() && !task.Unparsed {
+			task.ensureUID(tasks)
+			count++
+		}
+	}
+	return count
+}
+
+// Renumber sets the Task.Id of all tasks of the Document to their position among the task lines, starting at 1.
+func (doc *Document) Renumber() {
+	for i, task := range doc.Tasks() {
+		task.Id = i + 1
+	}
+}
@@ -0,0 +1,161 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTaskUID(t *testing.T) {
+	task, err := ParseTask("(A) 2014-01-12 Call Dad @Phone +Family uid:3f2a9c01b7d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "3f2a9c01b7d4"
+	testGot = task.UID()
+	if testGot != testExpected {
+		t.Errorf("Expected UID to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	// The fingerprint does not depend on priority, completion, tags or token order
+	first, _ := ParseTask("(A) 2014-01-12 Call Dad @Phone +Family due:2014-01-20")
+	second, _ := ParseTask("x 2014-01-13 2014-01-12 Call Dad +Family @Phone rec:1w")
+	if first.UID() != second.UID() || len(first.UID()) != 12 {
+		t.Errorf("Expected equal fingerprints, but got [%s] and [%s]", first.UID(), second.UID())
+	}
+	second.Todo = "Call Mom"
+	if first.UID() == second.UID() {
+		t.Errorf("Expected different fingerprints after editing the todo text, but got [%s] twice", first.UID())
+	}
+}
+
+func TestTaskListGetTaskByUID(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("Call Mom\nCall Dad uid:3f2a9c01b7d4\nPick up milk\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	milk := tasklist[2].UID()
+
+	// Inserting a line changes the ids, but not the stable identities
+	tasklist = append(TaskList{{Todo: "Water the plants"}}, tasklist...)
+	tasklist.Renumber()
+
+	task, err := tasklist.GetTaskByUID("3f2a9c01b7d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Todo != "Call Dad" || task.Id != 3 {
+		t.Errorf("Expected Task[3] to be [Call Dad], but got Task[%d] [%s]", task.Id, task.Todo)
+	}
+	task, err = tasklist.GetTaskByUID(milk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Todo != "Pick up milk" || task.Id != 4 {
+		t.Errorf("Expected Task[4] to be [Pick up milk], but got Task[%d] [%s]", task.Id, task.Todo)
+	}
+	task.Complete()
+	if !tasklist[3].Completed {
+		t.Errorf("Expected Task pointer to update the TaskList, but it didn't")
+	}
+
+	if _, err := tasklist.GetTaskByUID("000000000000"); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, but got [%v]", err)
+	}
+}
+
+func TestTaskListAssignUIDs(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("Call Mom\nCall Dad uid:3f2a9c01b7d4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = 1
+	testGot = tasklist.AssignUIDs()
+	if testGot != testExpected {
+		t.Errorf("Expected %d tasks to get a UID, but got %d", testExpected, testGot)
+	}
+	if !tasklist[0].HasUID() || len(tasklist[0].UID()) != 12 || tasklist[1].UID() != "3f2a9c01b7d4" {
+		t.Errorf("Expected all tasks to have a UID, but got [%s]", tasklist.String())
+	}
+
+	GenerateUIDs = true
+	defer func() { GenerateUIDs = false }()
+	task := Task{Todo: "Pick up milk"}
+	tasklist.AddTask(&task)
+	if !tasklist[2].HasUID() || tasklist[2].UID() == tasklist[0].UID() {
+		t.Errorf("Expected added Task to get a new UID, but got [%s]", tasklist[2].String())
+	}
+
+	doc, err := LoadDocumentFromReader(strings.NewReader("# Home\nCall Mom\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.AddTask(&Task{Todo: "Pick up milk"})
+	uid := doc.Tasks()[1].UID()
+	if !doc.Tasks()[1].HasUID() || !strings.HasSuffix(doc.String(), "Pick up milk uid:"+uid+"\n") {
+		t.Errorf("Expected added Task to get a UID, but got [%s]", doc.String())
+	}
+	if found, err := doc.GetTaskByUID(uid); err != nil || found.Todo != "Pick up milk" {
+		t.Errorf("Expected to find [Pick up milk], but got [%v] and error [%v]", found, err)
+	}
+}
+
+func TestTaskListAddTaskDuplicateUID(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("Call Dad uid:3f2a9c01b7d4\nWater the plants rec:1w uid:9c01b7d43f2a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	copied, err := ParseTask("Call Dad uid:3f2a9c01b7d4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasklist.AddTask(copied)
+	if !tasklist[2].HasUID() || tasklist[2].UID() == "3f2a9c01b7d4" {
+		t.Errorf("Expected added Task to get a new UID, but got [%s]", tasklist[2].String())
+	}
+	if task, err := tasklist.GetTaskByUID("3f2a9c01b7d4"); err != nil || task.Id != 1 {
+		t.Errorf("Expected UID to still belong to Task[1], but got [%v] and error [%v]", task, err)
+	}
+
+	next, err := tasklist.Complete(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.HasUID() {
+		t.Errorf("Expected next occurrence not to copy the UID, but got [%s]", next.String())
+	}
+	if task, err := tasklist.GetTaskByUID("9c01b7d43f2a"); err != nil || !task.Completed {
+		t.Errorf("Expected UID to still belong to the completed Task, but got [%v] and error [%v]", task, err)
+	}
+
+	doc, err := LoadDocumentFromReader(strings.NewReader("Call Dad uid:3f2a9c01b7d4\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.AddTask(&Task{Todo: "Call Dad", AdditionalTags: map[string]string{"uid": "3f2a9c01b7d4"}})
+	if uid := doc.Tasks()[1].UID(); uid == "3f2a9c01b7d4" || len(uid) != 12 {
+		t.Errorf("Expected added Task to get a new UID, but got [%s]", doc.String())
+	}
+}
+
+func TestAddTaskWithUIDOption(t *testing.T) {
+	tasklist := NewTaskList()
+	tasklist.AddTask(&Task{Todo: "Call Mom"})
+	tasklist.AddTaskWithUID(&Task{Todo: "Call Dad"})
+	if tasklist[0].HasUID() || !tasklist[1].HasUID() {
+		t.Errorf("Expected only the Task added with UID to have one, but got [%s]", tasklist.String())
+	}
+
+	doc := NewDocument()
+	doc.GenerateUIDs = true
+	doc.AddTask(&Task{Todo: "Call Mom"})
+	if !doc.Tasks()[0].HasUID() {
+		t.Errorf("Expected Task added to the Document to get a UID, but got [%s]", doc.String())
+	}
+}
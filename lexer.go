@@ -0,0 +1,202 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"strings"
+	"unicode"
+)
+
+// TokenType identifies what kind of element of a todo.txt task string a Token is.
+type TokenType int
+
+// Token types returned by the Lexer.
+const (
+	TOKEN_WORD           TokenType = iota // Part of the todo text: 'Call', 'Mom'
+	TOKEN_COMPLETED                       // Completion marker: 'x'
+	TOKEN_COMPLETED_DATE                  // Completed date following the completion marker: '2014-01-03'
+	TOKEN_PRIORITY                        // Priority: '(A)'
+	TOKEN_CREATED_DATE                    // Created date: '2014-01-01'
+	TOKEN_CONTEXT                         // Context: '@Phone'
+	TOKEN_PROJECT                         // Project: '+Family'
+	TOKEN_TAG                             // Additional tag: 'key:value'
+	TOKEN_DUE_DATE                        // Due date tag: 'due:2014-01-12'
+	TOKEN_LINK                            // Link, which is also part of the todo text: 'https://example.com/x'
+	TOKEN_THRESHOLD_DATE                  // Threshold date tag: 't:2014-01-10'
+	TOKEN_HIDDEN                          // Hidden tag: 'h:1'
+)
+
+var tokenTypeNames = []string{
+	TOKEN_WORD:           "word",
+	TOKEN_COMPLETED:      "completed",
+	TOKEN_COMPLETED_DATE: "completed date",
+	TOKEN_PRIORITY:       "priority",
+	TOKEN_CREATED_DATE:   "created date",
+	TOKEN_CONTEXT:        "context",
+	TOKEN_PROJECT:        "project",
+	TOKEN_TAG:            "tag",
+	TOKEN_DUE_DATE:       "due date",
+	TOKEN_LINK:           "link",
+	TOKEN_THRESHOLD_DATE: "threshold date",
+	TOKEN_HIDDEN:         "hidden",
+}
+
+// String returns a human readable name of the token type.
+func (tokenType TokenType) String() string {
+	if tokenType >= 0 && int(tokenType) < len(tokenTypeNames) {
+		return tokenTypeNames[tokenType]
+	}
+	return "unknown"
+}
+
+// Token represents a single element of a todo.txt task string.
+type Token struct {
+	Type  TokenType
+	Text  string // Raw token text, as found in the input.
+	Key   string // Tag key, only set for TOKEN_TAG, TOKEN_DUE_DATE, TOKEN_THRESHOLD_DATE and TOKEN_HIDDEN.
+	Value string // Token value without any markers: priority letter, date, context or project name, tag value, link, word without escaping backslash.
+	Start int    // Byte offset of the first character of the token in the input.
+	End   int    // Byte offset following the last character of the token in the input.
+}
+
+// Lexer states, used to recognize the completion, priority and created date prefix of a task.
+const (
+	stateStart         = iota // Nothing read yet
+	stateCompleted            // 'x' was read
+	stateCompletedDate        // 'x 2012-12-12' was read
+	statePriority             // '(A)' was read
+	stateBody                 // Everything else
+)
+
+// Lexer splits a todo.txt task string into tokens.
+type Lexer struct {
+	text   string
+	pos    int
+	state  int
+	parser *Parser
+}
+
+// NewLexer creates a new Lexer reading from the given task string, using the default options of NewParser().
+func NewLexer(text string) *Lexer {
+	return NewParser().NewLexer(text)
+}
+
+// Next returns the next token of the task string.
+// Returns false as second value if there are no more tokens left.
+func (lexer *Lexer) Next() (Token, bool) {
+	start := lexer.pos
+	for start < len(lexer.text) && isSpace(lexer.text[start]) {
+		start++
+	}
+	if start >= len(lexer.text) {
+		lexer.pos = start
+		return Token{}, false
+	}
+	end := start
+	for end < len(lexer.text) && !isSpace(lexer.text[end]) {
+		end++
+	}
+	lexer.pos = end
+
+	token := Token{Type: TOKEN_WORD, Text: lexer.text[start:end], Start: start, End: end}
+	token.Value = token.Text
+
+	// Escaped words are always part of the todo text, their value is the word without the backslash
+	if token.Text[0] == '\\' {
+		token.Value, lexer.state = token.Text[1:], stateBody
+		return token, true
+	}
+
+	// Prefix elements are only recognized if more text follows them
+	if lexer.state != stateBody && lexer.hasMore() {
+		isDate := lexer.parser.isDate(token.Text)
+		switch {
+		case lexer.state == stateStart && token.Text == "x":
+			token.Type, lexer.state = TOKEN_COMPLETED, stateCompleted
+			return token, true
+		case lexer.state == stateCompleted && isDate:
+			token.Type, lexer.state = TOKEN_COMPLETED_DATE, stateCompletedDate
+			return token, true
+		case lexer.state != statePriority && isPriority(token.Text):
+			token.Type, token.Value, lexer.state = TOKEN_PRIORITY, token.Text[1:2], statePriority
+			return token, true
+		case isDate:
+			token.Type, lexer.state = TOKEN_CREATED_DATE, stateBody
+			return token, true
+		}
+	}
+	lexer.state = stateBody
+
+	if token.Text[0] == '@' && hasLetter(token.Text[1:]) {
+		token.Type, token.Value = TOKEN_CONTEXT, token.Text[1:]
+	} else if token.Text[0] == '+' && hasLetter(token.Text[1:]) {
+		token.Type, token.Value = TOKEN_PROJECT, token.Text[1:]
+	} else if isLink(token.Text) {
+		token.Type, token.Value = TOKEN_LINK, strings.TrimRight(token.Text, ".,;:!?)]}>'\"")
+	} else if i := strings.IndexByte(token.Text, ':'); i > 0 && i < len(token.Text)-1 && lexer.parser.isTagKey(token.Text[:i]) {
+		token.Type, token.Key, token.Value = TOKEN_TAG, token.Text[:i], token.Text[i+1:]
+		switch { // due and threshold dates and 'h:1' are known addon tags, they have their own token types
+		case token.Key == "due":
+			token.Type = TOKEN_DUE_DATE
+		case token.Key == "t":
+			token.Type = TOKEN_THRESHOLD_DATE
+		case token.Key == "h" && token.Value == "1":
+			token.Type = TOKEN_HIDDEN
+		}
+	}
+	return token, true
+}
+
+// isPriority returns true if text is a priority: '(A)'
+func isPriority(text string) bool {
+	return len(text) == 3 && text[0] == '(' && text[1] >= 'A' && text[1] <= 'Z' && text[2] == ')'
+}
+
+// isLink returns true if text starts with a URL scheme, followed by '://' and anything else: 'https://example.com/x'
+func isLink(text string) bool {
+	if len(text) == 0 || !isASCIILetter(text[0]) {
+		return false
+	}
+	i := 1
+	for i < len(text) && (isASCIILetter(text[i]) || isDigit(text[i]) || text[i] == '+' || text[i] == '.' || text[i] == '-') {
+		i++
+	}
+	return strings.HasPrefix(text[i:], "://") && len(text) > i+3
+}
+
+// hasLetter returns true if text contains at least one letter.
+// Contexts and projects need one, so that phone numbers like '+1 555 1234' or '@ 10' are not recognized as such.
+func hasLetter(text string) bool {
+	return strings.IndexFunc(text, unicode.IsLetter) >= 0
+}
+
+// hasNonDigit returns true if text contains anything else than digits.
+// Tag keys need one, so that clock times like '10:30' are not recognized as tags.
+func hasNonDigit(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool { return r < '0' || r > '9' }) >= 0
+}
+
+// hasMore returns true if there is any non-whitespace text left after the current position.
+func (lexer *Lexer) hasMore() bool {
+	for i := lexer.pos; i < len(lexer.text); i++ {
+		if !isSpace(lexer.text[i]) {
+			return i > lexer.pos
+		}
+	}
+	return false
+}
+
+// Tokenize splits a todo.txt task string into all of its tokens, using the default options of NewParser().
+func Tokenize(text string) []Token {
+	return NewParser().Tokenize(text)
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,110 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	text := "x 2014-01-03 (C) 2014-01-01 @Go due:2014-01-12  Create golang Level:5 +go-todotxt"
+	tokens := Tokenize(text)
+
+	expected := []Token{
+		{Type: TOKEN_COMPLETED, Text: "x", Value: "x", Start: 0, End: 1},
+		{Type: TOKEN_COMPLETED_DATE, Text: "2014-01-03", Value: "2014-01-03", Start: 2, End: 12},
+		{Type: TOKEN_PRIORITY, Text: "(C)", Value: "C", Start: 13, End: 16},
+		{Type: TOKEN_CREATED_DATE, Text: "2014-01-01", Value: "2014-01-01", Start: 17, End: 27},
+		{Type: TOKEN_CONTEXT, Text: "@Go", Value: "Go", Start: 28, End: 31},
+		{Type: TOKEN_DUE_DATE, Text: "due:2014-01-12", Key: "due", Value: "2014-01-12", Start: 32, End: 46},
+		{Type: TOKEN_WORD, Text: "Create", Value: "Create", Start: 48, End: 54},
+		{Type: TOKEN_WORD, Text: "golang", Value: "golang", Start: 55, End: 61},
+		{Type: TOKEN_TAG, Text: "Level:5", Key: "Level", Value: "5", Start: 62, End: 69},
+		{Type: TOKEN_PROJECT, Text: "+go-todotxt", Value: "go-todotxt", Start: 70, End: 81},
+	}
+
+	testExpected = len(expected)
+	testGot = len(tokens)
+	if testGot != testExpected {
+		t.Fatalf("Expected %d tokens, but got %d: %v", testExpected, testGot, tokens)
+	}
+	for i := range expected {
+		testExpected = expected[i]
+		testGot = tokens[i]
+		if testGot != testExpected {
+			t.Errorf("Expected Token[%d] to be [%v], but got [%v]", i, testExpected, testGot)
+		}
+		testExpected = tokens[i].Text
+		testGot = text[tokens[i].Start:tokens[i].End]
+		if testGot != testExpected {
+			t.Errorf("Expected Token[%d] offsets to point to [%s], but got [%s]", i, testExpected, testGot)
+		}
+	}
+}
+
+func TestTokenizePrefix(t *testing.T) {
+	for text, expected := range map[string][]TokenType{
+		"(A) Call Mom":                 {TOKEN_PRIORITY, TOKEN_WORD, TOKEN_WORD},
+		"2014-01-01 (A) Call Mom":      {TOKEN_CREATED_DATE, TOKEN_WORD, TOKEN_WORD, TOKEN_WORD},
+		"x (A) 2014-01-01 Call":        {TOKEN_COMPLETED, TOKEN_PRIORITY, TOKEN_CREATED_DATE, TOKEN_WORD},
+		"x 2014-01-03 2014-01-01 Call": {TOKEN_COMPLETED, TOKEN_COMPLETED_DATE, TOKEN_CREATED_DATE, TOKEN_WORD},
+		"xylophone lesson":             {TOKEN_WORD, TOKEN_WORD},
+		"X 2012-01-01 Make":            {TOKEN_WORD, TOKEN_WORD, TOKEN_WORD},
+		"x":                            {TOKEN_WORD},
+		"(A)":                          {TOKEN_WORD},
+		"Call (A) x 2014-01-01":        {TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_WORD},
+		"@ + : a: :b @a +b":            {TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_CONTEXT, TOKEN_PROJECT},
+		"see https://example.com/x":    {TOKEN_WORD, TOKEN_LINK},
+		"meet at 10:30 due:2014-01-01": {TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_DUE_DATE},
+		"water t:2014-01-01 at:home":   {TOKEN_WORD, TOKEN_THRESHOLD_DATE, TOKEN_TAG},
+		"call +1 555 1234 @10 +Work":   {TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_WORD, TOKEN_PROJECT},
+	} {
+		tokens := Tokenize(text)
+		got := make([]TokenType, len(tokens))
+		for i := range tokens {
+			got[i] = tokens[i].Type
+		}
+
+		testExpected = expected
+		testGot = got
+		if len(got) != len(expected) {
+			t.Errorf("Expected [%s] to be tokenized as %v, but got %v", text, testExpected, testGot)
+			continue
+		}
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Errorf("Expected [%s] to be tokenized as %v, but got %v", text, testExpected, testGot)
+				break
+			}
+		}
+	}
+}
+
+func TestLexer(t *testing.T) {
+	lexer := NewLexer("  Call Mom  ")
+
+	token, ok := lexer.Next()
+	testExpected = "Call"
+	testGot = token.Text
+	if !ok || testGot != testExpected {
+		t.Errorf("Expected first token to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = 2
+	testGot = token.Start
+	if testGot != testExpected {
+		t.Errorf("Expected first token to start at [%d], but got [%d]", testExpected, testGot)
+	}
+
+	token, ok = lexer.Next()
+	testExpected = "Mom"
+	testGot = token.Text
+	if !ok || testGot != testExpected {
+		t.Errorf("Expected second token to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if token, ok = lexer.Next(); ok {
+		t.Errorf("Expected no more tokens, but got [%v]", token)
+	}
+}
@@ -0,0 +1,207 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// The methods in this file change a Task while keeping it consistent:
+// input is normalized and validated, Task.Original is rewritten in its token order, see *Task.PreservedString(),
+// and Task.Dirty is set, so that only changed tasks need to be written again.
+// Unparsed tasks can not be changed, they return ErrUnparsedTask, see Task.Unparsed.
+
+// SetPriority sets the priority of the task, 'A' to 'Z'. An empty priority removes it.
+// The priority can also be given as written in a task: '(A)'
+//
+// Returns ErrInvalidPriority for anything else, including lowercase letters.
+func (task *Task) SetPriority(priority string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	priority = strings.TrimSpace(priority)
+	if isPriority(priority) {
+		priority = priority[1:2]
+	}
+	if priority != "" && !isPriorityLetter(priority) {
+		return ErrInvalidPriority
+	}
+	if priority != task.Priority {
+		task.Priority = priority
+		task.changed()
+	}
+	return nil
+}
+
+// AddContext adds a context to the task, with or without leading '@'. Contexts the task already has are not added again.
+//
+// Returns ErrInvalidContext if the context is empty, contains whitespace or has no letter.
+func (task *Task) AddContext(context string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	context, err := normalizeName(context, "@", ErrInvalidContext)
+	if err != nil {
+		return err
+	}
+	if !containsString(task.Contexts, context) {
+		task.Contexts = insertSorted(task.Contexts, context)
+		task.changed()
+	}
+	return nil
+}
+
+// RemoveContext removes a context from the task, given with or without leading '@'.
+//
+// Returns ErrContextNotFound if the task does not have the context.
+func (task *Task) RemoveContext(context string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	var found bool
+	if task.Contexts, found = removeString(task.Contexts, strings.TrimPrefix(strings.TrimSpace(context), "@")); !found {
+		return ErrContextNotFound
+	}
+	task.changed()
+	return nil
+}
+
+// AddProject adds a project to the task, with or without leading '+'. Projects the task already has are not added again.
+//
+// Returns ErrInvalidProject if the project is empty, contains whitespace or has no letter.
+func (task *Task) AddProject(project string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	project, err := normalizeName(project, "+", ErrInvalidProject)
+	if err != nil {
+		return err
+	}
+	if !containsString(task.Projects, project) {
+		task.Projects = insertSorted(task.Projects, project)
+		task.changed()
+	}
+	return nil
+}
+
+// RemoveProject removes a project from the task, given with or without leading '+'.
+//
+// Returns ErrProjectNotFound if the task does not have the project.
+func (task *Task) RemoveProject(project string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	var found bool
+	if task.Projects, found = removeString(task.Projects, strings.TrimPrefix(strings.TrimSpace(project), "+")); !found {
+		return ErrProjectNotFound
+	}
+	task.changed()
+	return nil
+}
+
+// SetTag sets the additional tag with the given key to value.
+//
+// Returns ErrInvalidTagKey or ErrInvalidTagValue if the tag can not be written, see *Task.SetTagInt(),
+// or the error of reading the value as the registered type of the key, see RegisterTagType().
+// The keys 'due', 't' and 'h' can not be set, use SetDueDate(), Task.ThresholdDate and Task.Hidden instead.
+func (task *Task) SetTag(key, value string) error {
+	if err := task.parser().validateTag(key, value); err != nil {
+		return err
+	}
+	return task.setTag(key, value)
+}
+
+// DeleteTag removes the additional tag with the given key.
+//
+// Returns ErrTagNotFound if the task has no such tag.
+func (task *Task) DeleteTag(key string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	if _, found := task.AdditionalTags[key]; !found {
+		return ErrTagNotFound
+	}
+	delete(task.AdditionalTags, key)
+	task.changed()
+	return nil
+}
+
+// SetDueDate sets the due date of the task, keeping its wall clock date and time of day. A zero time removes the due date.
+// Seconds are dropped, as due dates are written with minutes at most.
+func (task *Task) SetDueDate(date time.Time) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	if !date.IsZero() {
+		date = wallClock(date).Truncate(time.Minute)
+	}
+	if !date.Equal(task.DueDate) {
+		task.DueDate = date
+		task.changed()
+	}
+	return nil
+}
+
+// SetTodo sets the todo text of the task, without leading and trailing whitespace, and updates Task.Links.
+// Words that would be read as something else are escaped when writing the task, see *Task.String().
+//
+// Returns ErrInvalidTodo if the text contains line breaks.
+func (task *Task) SetTodo(todo string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	if strings.ContainsAny(todo, "\n\r") {
+		return ErrInvalidTodo
+	}
+	parser := task.parser()
+	parsed, err := parser.ParseTask(parser.escaped(strings.Trim(todo, "\t\f ")))
+	if err != nil {
+		return err
+	}
+	if parsed.Todo != task.Todo {
+		task.Todo = parsed.Todo
+		task.Links = parsed.Links
+		task.changed()
+	}
+	return nil
+}
+
+// changed marks the task as dirty and rewrites Task.Original, keeping its token order and the options of its Parser.
+func (task *Task) changed() {
+	task.Dirty = true
+	if task.Original != "" && !task.Unparsed {
+		task.Original = task.PreservedString()
+	}
+}
+
+// normalizeName removes whitespace and the given prefix from a context or project name, and checks that it can be written.
+func normalizeName(name, prefix string, invalid error) (string, error) {
+	name = strings.TrimPrefix(strings.TrimSpace(name), prefix)
+	if name == "" || strings.ContainsAny(name, " \t\n\r\f") || !hasLetter(name) {
+		return "", invalid
+	}
+	return name, nil
+}
+
+// insertSorted inserts s into the sorted slice, keeping it sorted.
+func insertSorted(slice []string, s string) []string {
+	i := sort.SearchStrings(slice, s)
+	slice = append(slice, "")
+	copy(slice[i+1:], slice[i:])
+	slice[i] = s
+	return slice
+}
+
+// removeString returns slice without s, and if s was found in it.
+func removeString(slice []string, s string) ([]string, bool) {
+	for i, element := range slice {
+		if element == s {
+			return append(slice[:i:i], slice[i+1:]...), true
+		}
+	}
+	return slice, false
+}
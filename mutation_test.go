@@ -0,0 +1,183 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskMutation(t *testing.T) {
+	task, err := ParseTask("(B) Call Dad @Phone due:2014-01-12 +Family")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Dirty {
+		t.Errorf("Expected parsed Task not to be dirty, but it was")
+	}
+
+	for _, err := range []error{
+		task.SetPriority("(A)"),
+		task.AddContext("@Home"),
+		task.AddContext("Phone"),
+		task.RemoveProject("+Family"),
+		task.AddProject("Parents"),
+		task.SetTag("rec", "1w"),
+		task.SetDueDate(time.Date(2014, 1, 13, 14, 30, 0, 0, time.UTC)),
+		task.SetTodo("  Call Dad about https://example.com/party  "),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !task.Dirty {
+		t.Errorf("Expected changed Task to be dirty, but it wasn't")
+	}
+
+	testExpected = "(A) Call Dad about https://example.com/party @Phone due:2014-01-13T14:30 @Home +Parents rec:1w"
+	testGot = task.Original
+	if testGot != testExpected {
+		t.Errorf("Expected Task.Original to be [%s], but got [%s]", testExpected, testGot)
+	}
+	parsed, err := ParseTask(task.Original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !task.equals(parsed) || strings.Join(task.Links, " ") != "https://example.com/party" {
+		t.Errorf("Expected Task.Original to be parsed as [%v], but got [%v]", task, parsed)
+	}
+
+	if err := task.DeleteTag("rec"); err != nil {
+		t.Error(err)
+	}
+	if err := task.RemoveContext("Phone"); err != nil {
+		t.Error(err)
+	}
+	task.SetDueDate(time.Time{})
+	task.SetPriority("")
+	testExpected = "Call Dad about https://example.com/party @Home +Parents"
+	testGot = task.Original
+	if testGot != testExpected {
+		t.Errorf("Expected Task.Original to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskMutationParser(t *testing.T) {
+	parser := &Parser{DateLayouts: []string{"02.01.2006"}, TagTypes: map[string]TagType{"est": TAG_DURATION}}
+	task, err := parser.ParseTask("01.02.2024 Call Mom due:05.02.2024")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := task.AddContext("home"); err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "01.02.2024 Call Mom due:05.02.2024 @home"
+	testGot = task.Original
+	if testGot != testExpected {
+		t.Errorf("Expected Task.Original to be [%s], but got [%s]", testExpected, testGot)
+	}
+	parsed, err := parser.ParseTask(task.Original)
+	if err != nil || !task.equals(parsed) {
+		t.Errorf("Expected Task.Original to be parsed as [%v], but got [%v] and error [%v]", task, parsed, err)
+	}
+
+	if err := task.SetTag("est", "soon"); err == nil {
+		t.Errorf("Expected the tag types of the Parser to be checked, but got [%v]", err)
+	}
+}
+
+func TestTaskMutationInvalid(t *testing.T) {
+	task, err := ParseTask("(B) Call Dad @Phone +Family note:x")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		err      error
+		expected error
+	}{
+		{task.SetPriority("a"), ErrInvalidPriority},
+		{task.SetPriority("AB"), ErrInvalidPriority},
+		{task.AddContext("at home"), ErrInvalidContext},
+		{task.AddContext("@"), ErrInvalidContext},
+		{task.AddContext("@10"), ErrInvalidContext},
+		{task.AddProject("big project"), ErrInvalidProject},
+		{task.RemoveContext("Home"), ErrContextNotFound},
+		{task.RemoveProject("Work"), ErrProjectNotFound},
+		{task.SetTag("due", "2014-01-12"), ErrInvalidTagKey},
+		{task.SetTag("note", "two words"), ErrInvalidTagValue},
+		{task.DeleteTag("missing"), ErrTagNotFound},
+		{task.SetTodo("two\nlines"), ErrInvalidTodo},
+	} {
+		if !errors.Is(test.err, test.expected) {
+			t.Errorf("Expected error [%v], but got [%v]", test.expected, test.err)
+		}
+	}
+
+	// Adding an existing context again is not a change
+	if err := task.AddContext("Phone"); err != nil || task.Dirty || len(task.Contexts) != 1 {
+		t.Errorf("Expected Task to be unchanged, but got [%v] and error [%v]", task, err)
+	}
+
+	RegisterTagType("pages", TAG_INT)
+	defer UnregisterTagType("pages")
+	if err := task.SetTag("pages", "many"); err == nil {
+		t.Errorf("Expected SetTag to fail because of invalid pages tag, but it didn't!")
+	}
+}
+
+func TestDocumentDirtyTasks(t *testing.T) {
+	input := "# Home\n  (B) Call Dad   @Phone\n\tPick up milk  @GroceryStore\n"
+	doc, err := LoadDocumentFromReader(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	task, _ := doc.GetTask(2)
+	task.SetTodo("Pick up milk and bread")
+	testExpected = "# Home\n  (B) Call Dad   @Phone\nPick up milk and bread  @GroceryStore\n"
+	testGot = doc.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Document to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskMutationUnparsed(t *testing.T) {
+	parser := &Parser{DateLayouts: []string{DateLayout}, Lenient: true}
+	tasklist, err := parser.LoadFromReader(strings.NewReader("Call Mom due:tomorrow\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := &tasklist[0]
+
+	for _, err := range []error{
+		task.SetPriority("A"),
+		task.AddContext("Phone"),
+		task.AddProject("Family"),
+		task.SetTag("note", "x"),
+		task.SetTagInt("count", 1),
+		task.SetDueDate(time.Date(2014, 1, 13, 0, 0, 0, 0, time.UTC)),
+		task.SetTodo("Call Dad"),
+	} {
+		if !errors.Is(err, ErrUnparsedTask) {
+			t.Errorf("Expected ErrUnparsedTask, but got [%v]", err)
+		}
+	}
+	task.Complete()
+	if _, err := tasklist.Complete(1); !errors.Is(err, ErrUnparsedTask) {
+		t.Errorf("Expected ErrUnparsedTask, but got [%v]", err)
+	}
+	if task.Completed || task.Dirty {
+		t.Errorf("Expected unparsed Task not to be changed, but got [%v]", task)
+	}
+	testExpected = "Call Mom due:tomorrow\n"
+	testGot = tasklist.String()
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
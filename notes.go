@@ -0,0 +1,257 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// HasNote returns true if the task has a 'note:' tag, see Notes.
+func (task *Task) HasNote() bool {
+	_, found := task.AdditionalTags["note"]
+	return found
+}
+
+// NoteId returns the value of the 'note:' tag of the task, the name of its note in the notes directory.
+//
+// Returns ErrNoteNotFound if the task has no 'note:' tag, or ErrInvalidNoteId if the value can not be used as a file name.
+func (task *Task) NoteId() (string, error) {
+	id, found := task.AdditionalTags["note"]
+	if !found {
+		return "", ErrNoteNotFound
+	}
+	if !isNoteId(id) {
+		return "", ErrInvalidNoteId
+	}
+	return id, nil
+}
+
+// Notes stores multi-line notes of tasks as text files in a notes directory.
+//
+// A task refers to its note with a 'note:' tag, whose value is the file name of the note without extension:
+//
+//	"Write report note:3f2a9c1b7d4e" has its note in the file "notes/3f2a9c1b7d4e.txt"
+//
+// Notes only modifies the given tasks, they still have to be written back to todo.txt afterwards.
+// Tasks removed with Notes.RemoveTaskById() or Notes.RemoveTask() lose their note as well.
+// Archived tasks keep their 'note:' tag and their note, so the TaskList of done.txt has to be passed to Notes.Orphans() too.
+type Notes struct {
+	Dir string // Notes directory, created when the first note is written.
+}
+
+// NewNotes creates a new Notes, using the directory "notes" in the same directory as the given todo.txt file.
+func NewNotes(todoFilename string) *Notes {
+	return &Notes{Dir: filepath.Join(filepath.Dir(todoFilename), "notes")}
+}
+
+// Create writes a new note for the task and adds a 'note:' tag with a new random id to it, see NewUID().
+//
+// Returns ErrNoteExists if the task already has a 'note:' tag. The task is not modified if the note can not be written.
+func (notes *Notes) Create(task *Task, text string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	if task.HasNote() {
+		return ErrNoteExists
+	}
+	if err := os.MkdirAll(notes.Dir, 0750); err != nil {
+		return err
+	}
+
+	id := NewUID()
+	file, err := os.OpenFile(notes.filename(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, text); err != nil {
+		file.Close()
+		os.Remove(notes.filename(id))
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(notes.filename(id))
+		return err
+	}
+	return task.setTag("note", id)
+}
+
+// Read returns the text of the note of the task.
+//
+// Returns ErrNoteNotFound if the task has no 'note:' tag or its note does not exist.
+func (notes *Notes) Read(task *Task) (string, error) {
+	id, err := task.NoteId()
+	if err != nil {
+		return "", err
+	}
+	data, err := ioutil.ReadFile(notes.filename(id))
+	if os.IsNotExist(err) {
+		return "", ErrNoteNotFound
+	} else if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Append adds text to the end of the note of the task, on a new line. A new note is created if the task has none yet, see Notes.Create().
+func (notes *Notes) Append(task *Task, text string) error {
+	if !task.HasNote() {
+		return notes.Create(task, text)
+	}
+	current, err := notes.Read(task)
+	if err != nil && err != ErrNoteNotFound {
+		return err
+	}
+	if current != "" && !strings.HasSuffix(current, "\n") {
+		text = "\n" + text
+	}
+
+	id, _ := task.NoteId()
+	if err := os.MkdirAll(notes.Dir, 0750); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(notes.filename(id), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(file, text); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Delete removes the note of the task and its 'note:' tag. A missing note file is not an error.
+//
+// Returns ErrNoteNotFound if the task has no 'note:' tag.
+func (notes *Notes) Delete(task *Task) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	id, err := task.NoteId()
+	if err != nil {
+		return err
+	}
+	if err := notes.remove(id); err != nil {
+		return err
+	}
+	return task.DeleteTag("note")
+}
+
+// RemoveTaskById removes any Task with given Task 'id' from the TaskList, together with its note, see *TaskList.RemoveTaskById().
+// Notes still referenced by another task of the TaskList are kept.
+// Returns ErrTaskNotFound if no Task was removed.
+func (notes *Notes) RemoveTaskById(tasklist *TaskList, id int) error {
+	return notes.removeTasks(tasklist, func(task *Task) bool {
+		return task.Id == id
+	})
+}
+
+// RemoveTask removes any Task from the TaskList with the same String representation as the given Task, together with its note, see *TaskList.RemoveTask().
+// Notes still referenced by another task of the TaskList are kept.
+// Returns ErrTaskNotFound if no Task was removed.
+func (notes *Notes) RemoveTask(tasklist *TaskList, task Task) error {
+	return notes.removeTasks(tasklist, func(t *Task) bool {
+		return t.String() == task.String()
+	})
+}
+
+// Orphans returns the ids of all notes in the notes directory that no task of the given TaskLists refers to, sorted by id.
+// Returns no ids if the notes directory does not exist yet.
+func (notes *Notes) Orphans(tasklists ...TaskList) ([]string, error) {
+	files, err := ioutil.ReadDir(notes.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool)
+	for _, tasklist := range tasklists {
+		for _, task := range tasklist {
+			if id, found := task.AdditionalTags["note"]; found {
+				used[id] = true
+			}
+		}
+	}
+
+	var orphans []string
+	for _, file := range files {
+		id := strings.TrimSuffix(file.Name(), ".txt")
+		if file.IsDir() || id == file.Name() || used[id] {
+			continue
+		}
+		orphans = append(orphans, id)
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
+
+// removeTasks removes all tasks matching the given function from the TaskList, and deletes their notes
+// unless a remaining task still refers to them.
+func (notes *Notes) removeTasks(tasklist *TaskList, remove func(task *Task) bool) error {
+	var newList TaskList
+	var removed []string
+	found := false
+	for i := range *tasklist {
+		task := &(*tasklist)[i]
+		if !remove(task) {
+			newList = append(newList, *task)
+			continue
+		}
+		found = true
+		if id, err := task.NoteId(); err == nil {
+			removed = append(removed, id)
+		}
+	}
+	if !found {
+		return ErrTaskNotFound
+	}
+
+	used := make(map[string]bool)
+	for _, task := range newList {
+		used[task.AdditionalTags["note"]] = true
+	}
+	for _, id := range removed {
+		if !used[id] {
+			if err := notes.remove(id); err != nil {
+				return err
+			}
+		}
+	}
+
+	*tasklist = newList
+	return nil
+}
+
+// filename returns the path of the note with the given id.
+func (notes *Notes) filename(id string) string {
+	return filepath.Join(notes.Dir, id+".txt")
+}
+
+// remove deletes the note with the given id, if it exists.
+func (notes *Notes) remove(id string) error {
+	if err := os.Remove(notes.filename(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// isNoteId returns true if text can be used as the file name of a note: letters, digits, '-' and '_' only.
+func isNoteId(text string) bool {
+	if text == "" {
+		return false
+	}
+	for _, r := range text {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNotes(t *testing.T) {
+	dir := t.TempDir()
+	notes := NewNotes(filepath.Join(dir, "todo.txt"))
+
+	testExpected = filepath.Join(dir, "notes")
+	testGot = notes.Dir
+	if testGot != testExpected {
+		t.Errorf("Expected notes directory to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task, err := ParseTask("(A) Write report @Office")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notes.Read(task); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Expected ErrNoteNotFound, but got [%v]", err)
+	}
+
+	if err := notes.Create(task, "Outline:\n- Introduction"); err != nil {
+		t.Fatal(err)
+	}
+	if err := notes.Create(task, "Again"); !errors.Is(err, ErrNoteExists) {
+		t.Errorf("Expected ErrNoteExists, but got [%v]", err)
+	}
+	id, err := task.NoteId()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "(A) Write report @Office note:" + id
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+	if !task.Dirty {
+		t.Errorf("Expected Task to be dirty after creating its note, but it wasn't")
+	}
+
+	if err := notes.Append(task, "- Results\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := notes.Append(task, "- Summary"); err != nil {
+		t.Fatal(err)
+	}
+	text, err := notes.Read(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Outline:\n- Introduction\n- Results\n- Summary"
+	testGot = text
+	if testGot != testExpected {
+		t.Errorf("Expected note to be [%s], but got [%s]", testExpected, testGot)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "notes", id+".txt"))
+	if err != nil || string(data) != text {
+		t.Errorf("Expected note file to contain [%s], but got [%s] and error [%v]", text, data, err)
+	}
+
+	if err := notes.Delete(task); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes", id+".txt")); !os.IsNotExist(err) {
+		t.Errorf("Expected note file to be deleted, but got [%v]", err)
+	}
+	testExpected = "(A) Write report @Office"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+	if err := notes.Delete(task); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Expected ErrNoteNotFound, but got [%v]", err)
+	}
+
+	// Appending to a task without note creates one
+	if err := notes.Append(task, "Started"); err != nil {
+		t.Fatal(err)
+	}
+	if text, err := notes.Read(task); err != nil || text != "Started" {
+		t.Errorf("Expected note to be [Started], but got [%s] and error [%v]", text, err)
+	}
+}
+
+func TestNotesInvalid(t *testing.T) {
+	notes := NewNotes(filepath.Join(t.TempDir(), "todo.txt"))
+
+	task, err := ParseTask("Write report note:../todo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notes.Read(task); !errors.Is(err, ErrInvalidNoteId) {
+		t.Errorf("Expected ErrInvalidNoteId, but got [%v]", err)
+	}
+	if err := notes.Delete(task); !errors.Is(err, ErrInvalidNoteId) {
+		t.Errorf("Expected ErrInvalidNoteId, but got [%v]", err)
+	}
+
+	task, err = ParseTask("Write report note:abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := notes.Read(task); !errors.Is(err, ErrNoteNotFound) {
+		t.Errorf("Expected ErrNoteNotFound for a missing note file, but got [%v]", err)
+	}
+
+	task = &Task{Original: "(A)", Unparsed: true}
+	if err := notes.Create(task, "Text"); !errors.Is(err, ErrUnparsedTask) {
+		t.Errorf("Expected ErrUnparsedTask, but got [%v]", err)
+	}
+	if orphans, err := notes.Orphans(); err != nil || len(orphans) != 0 {
+		t.Errorf("Expected no orphaned notes, but got %v and error [%v]", orphans, err)
+	}
+}
+
+func TestNotesRemoveTask(t *testing.T) {
+	dir := t.TempDir()
+	notes := NewNotes(filepath.Join(dir, "todo.txt"))
+
+	tasklist, err := LoadFromReader(strings.NewReader("Write report\nCall Mom\nBuy milk\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range tasklist {
+		if err := notes.Create(&tasklist[i], fmt.Sprintf("Note %d", i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := make([]string, len(tasklist))
+	for i := range tasklist {
+		ids[i], _ = tasklist[i].NoteId()
+	}
+
+	if err := notes.RemoveTaskById(&tasklist, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := notes.RemoveTaskById(&tasklist, 1); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, but got [%v]", err)
+	}
+	if err := notes.RemoveTask(&tasklist, tasklist[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = 1
+	testGot = len(tasklist)
+	if testGot != testExpected {
+		t.Fatalf("Expected TaskList to contain %d tasks, but got %d", testExpected, testGot)
+	}
+	for i, id := range ids {
+		_, err := os.Stat(filepath.Join(dir, "notes", id+".txt"))
+		if deleted := os.IsNotExist(err); deleted != (i < 2) {
+			t.Errorf("Expected note %d to be deleted [%v], but got [%v]", i+1, i < 2, deleted)
+		}
+	}
+
+	// An archived task keeps its note, a note without task is orphaned
+	done, err := LoadFromReader(strings.NewReader("x 2014-01-12 Water plants note:abc\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"abc", "def"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, "notes", id+".txt"), []byte(id), 0640); err != nil {
+			t.Fatal(err)
+		}
+	}
+	orphans, err := notes.Orphans(tasklist, done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "[def]"
+	testGot = fmt.Sprint(orphans)
+	if testGot != testExpected {
+		t.Errorf("Expected orphaned notes to be %s, but got %s", testExpected, testGot)
+	}
+	orphans, err = notes.Orphans(tasklist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "[abc def]"
+	testGot = fmt.Sprint(orphans)
+	if testGot != testExpected {
+		t.Errorf("Expected orphaned notes to be %s, but got %s", testExpected, testGot)
+	}
+}
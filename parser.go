@@ -0,0 +1,481 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Parser holds the options used for reading todo.txt tasks.
+//
+// A Parser can be shared between goroutines, as long as its options are not modified anymore.
+type Parser struct {
+	CommentPrefix string                // Lines starting with this prefix are comments. Comments are disabled if empty.
+	DateLayouts   []string              // Layouts tried in order when parsing dates. Layouts must not contain whitespace.
+	Lenient       bool                  // Keep lines that can not be parsed as unparsed tasks, instead of aborting. See Task.Unparsed.
+	TagKey        func(key string) bool // Decides if the key of a 'key:value' word is a tag key. See DefaultTagKey if nil.
+	TagTypes      map[string]TagType    // Types of tag values checked by ParseTask. The registry of RegisterTagType() is used if nil.
+	Priority      PriorityPolicy        // What happens to the priority of tasks when completing them, and how completed tasks are read. See PriorityPolicy.
+	Location      *time.Location        // Time zone used to decide which calendar day it is now for the tasks read, see *Task.IsOverdue(). Location is used if nil.
+	Clock         Clock                 // Clock used by the time dependent methods of the tasks read, like *Task.Complete(). DefaultClock is used if nil.
+}
+
+var (
+	// referenceTime is formatted with a date layout to find out what dates in that layout look like.
+	referenceTime = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	// dateShapes caches referenceTime formatted with each date layout seen so far.
+	dateShapes sync.Map
+)
+
+// NewParser creates a new Parser, using the package level variables IgnoreComments, DateLayout, Lenient, CompletedPriority and Location as defaults.
+func NewParser() *Parser {
+	parser := &Parser{
+		DateLayouts: []string{DateLayout},
+		Lenient:     Lenient,
+		Priority:    CompletedPriority,
+		Location:    Location,
+	}
+	if IgnoreComments {
+		parser.CommentPrefix = "#"
+	}
+	return parser
+}
+
+// DefaultTagKey accepts tag keys consisting of letters, digits, '_' and '-', as long as they are not only digits.
+// This is used by a Parser if its TagKey option is not set.
+func DefaultTagKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; !isASCIILetter(c) && !isDigit(c) && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return hasNonDigit(key)
+}
+
+// NewLexer creates a new Lexer reading from the given task string, using the options of the Parser.
+func (parser *Parser) NewLexer(text string) *Lexer {
+	return &Lexer{text: text, parser: parser}
+}
+
+// Tokenize splits a todo.txt task string into all of its tokens, using the options of the Parser.
+func (parser *Parser) Tokenize(text string) []Token {
+	var tokens []Token
+	lexer := parser.NewLexer(text)
+	for token, ok := lexer.Next(); ok; token, ok = lexer.Next() {
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
+
+// ParseTask parses the input text string into a Task struct.
+//
+// Words starting with a backslash are always part of the todo text, the backslash itself is removed: '\@Home', '\\path'
+// This is the escaping used by *Writer.Format() for todo text that would otherwise be read as something else.
+//
+// Returns a *ParseError if any of the dates in the text can not be parsed,
+// or the value of a tag does not match its registered type, see RegisterTagType().
+func (parser *Parser) ParseTask(text string) (*Task, error) {
+	task := Task{parsedBy: parser}
+	task.Original = strings.Trim(text, "\t\n\r ")
+
+	// function for creating a *ParseError pointing at the value of the token
+	parseError := func(token Token, field string, err error) error {
+		return &ParseError{
+			Column: token.Start + len(token.Text) - len(token.Value) + 1,
+			Text:   task.Original,
+			Field:  field,
+			Err:    err,
+		}
+	}
+
+	// function for parsing dates, returning a *ParseError pointing at the token
+	parseDate := func(token Token, field string) (time.Time, error) {
+		parse := parser.parseDate
+		if token.Type == TOKEN_DUE_DATE {
+			parse = parser.parseDateTime
+		}
+		date, err := parse(token.Value)
+		if err != nil {
+			return date, parseError(token, field, err)
+		}
+		return date, nil
+	}
+
+	var todo strings.Builder
+	var err error
+	end := 0
+	lexer := parser.NewLexer(task.Original)
+	for token, ok := lexer.Next(); ok; token, ok = lexer.Next() {
+		switch token.Type {
+		case TOKEN_COMPLETED:
+			task.Completed = true
+		case TOKEN_COMPLETED_DATE:
+			if task.CompletedDate, err = parseDate(token, "completed"); err != nil {
+				return nil, err
+			}
+		case TOKEN_PRIORITY:
+			task.Priority = token.Value
+		case TOKEN_CREATED_DATE:
+			if task.CreatedDate, err = parseDate(token, "created"); err != nil {
+				return nil, err
+			}
+		case TOKEN_CONTEXT:
+			if !containsString(task.Contexts, token.Value) {
+				task.Contexts = append(task.Contexts, token.Value)
+			}
+		case TOKEN_PROJECT:
+			if !containsString(task.Projects, token.Value) {
+				task.Projects = append(task.Projects, token.Value)
+			}
+		case TOKEN_HIDDEN:
+			task.Hidden = true
+		case TOKEN_TAG, TOKEN_DUE_DATE, TOKEN_THRESHOLD_DATE:
+			if task.AdditionalTags == nil {
+				task.AdditionalTags = make(map[string]string)
+			}
+			// due and threshold dates are known addon tags, they have their own struct fields
+			switch token.Type {
+			case TOKEN_DUE_DATE:
+				if task.DueDate, err = parseDate(token, "due"); err != nil {
+					return nil, err
+				}
+			case TOKEN_THRESHOLD_DATE:
+				if task.ThresholdDate, err = parseDate(token, "threshold"); err != nil {
+					return nil, err
+				}
+			default:
+				if err := parser.validateTag(token.Key, token.Value); err != nil {
+					return nil, parseError(token, token.Key, err)
+				}
+				task.AdditionalTags[token.Key] = token.Value
+			}
+		case TOKEN_WORD:
+			// Keep the whitespace in front of each word, to leave the todo text as it is
+			todo.WriteString(task.Original[end:token.Start])
+			todo.WriteString(token.Value)
+		case TOKEN_LINK:
+			task.Links = append(task.Links, token.Value)
+			todo.WriteString(task.Original[end:token.Start])
+			todo.WriteString(token.Text)
+		}
+		end = token.End
+	}
+	sort.Strings(task.Contexts)
+	sort.Strings(task.Projects)
+
+	// 'at:14:30' adds a time of day to a due date without one
+	if at, found := task.AdditionalTags["at"]; found && task.HasDueDate() && !task.HasDueTime() {
+		if clock, err := time.Parse(DueTimeLayout, at); err == nil && clock.Hour()+clock.Minute() > 0 {
+			task.DueDate = task.DueDate.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+			delete(task.AdditionalTags, "at")
+		}
+	}
+
+	// Completed tasks may use either a priority prefix or a 'pri:' tag, read both the same way
+	parser.Priority.normalize(&task)
+
+	// Trim any remaining whitespaces from Todo text
+	task.Todo = strings.Trim(todo.String(), "\t\n\r\f ")
+
+	return &task, nil
+}
+
+// LoadFromFile loads and returns a TaskList from *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdin.
+func (parser *Parser) LoadFromFile(file *os.File) (TaskList, error) {
+	return parser.LoadFromReader(file)
+}
+
+// LoadFromReader loads and returns a TaskList from any io.Reader.
+func (parser *Parser) LoadFromReader(r io.Reader) (TaskList, error) {
+	tasklist := TaskList{}
+	if err := parser.loadTaskList(&tasklist, r); err != nil {
+		return nil, err
+	}
+	return tasklist, nil
+}
+
+// LoadFromFilename loads and returns a TaskList from a file (most likely called "todo.txt").
+func (parser *Parser) LoadFromFilename(filename string) (TaskList, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parser.LoadFromFile(file)
+}
+
+// LoadDocumentFromFile loads and returns a Document from *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdin.
+func (parser *Parser) LoadDocumentFromFile(file *os.File) (*Document, error) {
+	return parser.LoadDocumentFromReader(file)
+}
+
+// LoadDocumentFromReader loads and returns a Document from any io.Reader.
+func (parser *Parser) LoadDocumentFromReader(r io.Reader) (*Document, error) {
+	doc := NewDocument()
+	if err := parser.loadDocument(doc, r); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// LoadDocumentFromFilename loads and returns a Document from a file (most likely called "todo.txt").
+func (parser *Parser) LoadDocumentFromFilename(filename string) (*Document, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parser.LoadDocumentFromFile(file)
+}
+
+// loadTaskList clears the TaskList and fills it with the tasks read from r.
+//
+// Lines that can not be parsed are skipped, all of their errors are returned together as ParseErrors.
+// If the Parser is lenient, such lines are kept as unparsed tasks instead, and no error is returned.
+func (parser *Parser) loadTaskList(tasklist *TaskList, r io.Reader) error {
+	*tasklist = []Task{} // Empty tasklist
+
+	var parseErrors ParseErrors
+	decoder := parser.NewDecoder(r)
+	// Next() stops at every *ParseError, continue with the following line after collecting it
+	for decoder.Next() || decoder.Err() != nil {
+		if err := decoder.Err(); err != nil {
+			parseError, ok := err.(*ParseError)
+			if !ok {
+				return err
+			}
+			parseErrors = append(parseErrors, parseError)
+			continue
+		}
+		*tasklist = append(*tasklist, *decoder.Task())
+	}
+	if len(parseErrors) > 0 {
+		return parseErrors
+	}
+
+	return nil
+}
+
+// loadDocument clears the Document and fills it with all lines read from r.
+// The Parser is kept by the Document, to write its tasks back the way they were read.
+func (parser *Parser) loadDocument(doc *Document, r io.Reader) error {
+	doc.Lines = nil
+	doc.Parser = parser
+
+	var parseErrors ParseErrors
+	taskId := 1
+	lineNumber := 0
+	reader := bufio.NewReader(r)
+	for {
+		text, ending, err := readLine(reader)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		lineNumber++
+
+		line := &Line{Type: LINE_TASK, Text: text, Ending: ending, parser: parser}
+		trimmed := strings.Trim(line.Text, "\t\n\r ")
+		if trimmed == "" {
+			line.Type = LINE_BLANK
+		} else if parser.isComment(trimmed) {
+			line.Type = LINE_COMMENT
+		} else if task, parseErr := parser.parseLine(line.Text, lineNumber); parseErr != nil {
+			if parseError, ok := parseErr.(*ParseError); ok {
+				parseErrors = append(parseErrors, parseError)
+				continue
+			}
+			return parseErr
+		} else {
+			task.Id = taskId
+			line.Task = task
+			taskId++
+		}
+		doc.Lines = append(doc.Lines, line)
+	}
+	if len(parseErrors) > 0 {
+		return parseErrors
+	}
+
+	return nil
+}
+
+// parseLine parses a single line of a todo.txt file into a Task.
+//
+// Errors are returned as *ParseError, including the line number and text.
+// If the Parser is lenient, an unparsed Task is returned instead.
+func (parser *Parser) parseLine(line string, lineNumber int) (*Task, error) {
+	text := strings.Trim(line, "\t\n\r ")
+	task, err := parser.ParseTask(text)
+	if err == nil {
+		return task, nil
+	}
+
+	parseError, ok := err.(*ParseError)
+	if !ok {
+		return nil, err
+	}
+	parseError.Line = lineNumber
+	parseError.Column += len(line) - len(strings.TrimLeft(line, "\t\n\r "))
+	parseError.Text = line
+	if !parser.Lenient {
+		return nil, parseError
+	}
+	return &Task{Original: text, Todo: text, Unparsed: true, Warning: parseError, parsedBy: parser}, nil
+}
+
+// isComment returns true if the trimmed line text is a comment.
+func (parser *Parser) isComment(text string) bool {
+	return parser.CommentPrefix != "" && strings.HasPrefix(text, parser.CommentPrefix)
+}
+
+// isTagKey returns true if key can be used as the key of an additional tag.
+func (parser *Parser) isTagKey(key string) bool {
+	if parser.TagKey == nil {
+		return DefaultTagKey(key)
+	}
+	return parser.TagKey(key)
+}
+
+// needsEscape returns true if word has to be escaped with a backslash to be read back as part of the todo text.
+// The first word of the todo text also has to be escaped if it looks like a completion marker, priority or date.
+func (parser *Parser) needsEscape(word string, first bool) bool {
+	if word[0] == '\\' {
+		return true
+	}
+	if first && (word == "x" || isPriority(word) || parser.isDate(word)) {
+		return true
+	}
+	lexer := Lexer{text: word, state: stateBody, parser: parser}
+	token, _ := lexer.Next()
+	return token.Type != TOKEN_WORD && token.Type != TOKEN_LINK
+}
+
+// layouts returns the date layouts of the Parser, falling back to DateLayout.
+func (parser *Parser) layouts() []string {
+	if len(parser.DateLayouts) == 0 {
+		return []string{DateLayout}
+	}
+	return parser.DateLayouts
+}
+
+// isDate returns true if text looks like a date in any of the date layouts.
+// The date itself is not validated, this is done by parseDate.
+func (parser *Parser) isDate(text string) bool {
+	for _, layout := range parser.layouts() {
+		shape := dateShape(layout)
+		if len(shape) != len(text) {
+			continue
+		}
+		matches := true
+		for i := 0; i < len(shape) && matches; i++ {
+			if isDigit(shape[i]) {
+				matches = isDigit(text[i])
+			} else {
+				matches = shape[i] == text[i]
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDate parses text with the first matching date layout.
+// Returns the error of the first layout if none of them matches.
+func (parser *Parser) parseDate(text string) (time.Time, error) {
+	var firstErr error
+	for _, layout := range parser.layouts() {
+		if layout == "2006-01-02" {
+			if date, ok := parseISODate(text); ok {
+				return date, nil
+			}
+		}
+		date, err := time.Parse(layout, text)
+		if err == nil {
+			return date, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return time.Time{}, firstErr
+}
+
+// parseDateTime parses a due date, which can have a time of day appended: '2014-01-12T14:30'
+func (parser *Parser) parseDateTime(text string) (time.Time, error) {
+	i := len(text) - len(DueTimeLayout) - 1
+	if i <= 0 || text[i] != 'T' {
+		return parser.parseDate(text)
+	}
+	date, err := parser.parseDate(text[:i])
+	if err != nil {
+		return date, err
+	}
+	clock, err := time.Parse(DueTimeLayout, text[i+1:])
+	if err != nil {
+		return time.Time{}, err
+	}
+	return date.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute), nil
+}
+
+// dateShape returns what dates in the given layout look like, which is cached for each layout.
+func dateShape(layout string) string {
+	if shape, found := dateShapes.Load(layout); found {
+		return shape.(string)
+	}
+	shape := referenceTime.Format(layout)
+	dateShapes.Store(layout, shape)
+	return shape
+}
+
+// parseISODate parses a valid 'YYYY-MM-DD' date without going through time.Parse, which is the common case.
+// Returns false for anything else, which is then left to time.Parse to report the error.
+func parseISODate(text string) (time.Time, bool) {
+	if len(text) != 10 || text[4] != '-' || text[7] != '-' {
+		return time.Time{}, false
+	}
+	number := func(digits string) int {
+		n := 0
+		for i := 0; i < len(digits); i++ {
+			if !isDigit(digits[i]) {
+				return -1
+			}
+			n = n*10 + int(digits[i]-'0')
+		}
+		return n
+	}
+	year, month, day := number(text[0:4]), number(text[5:7]), number(text[8:10])
+	if year < 0 || month < 1 || month > 12 || day < 1 {
+		return time.Time{}, false
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day { // day out of range for this month, time.Date normalized it
+		return time.Time{}, false
+	}
+	return date, true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,216 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+var (
+	testInputParser = "testdata/parser_todo.txt"
+)
+
+func TestNewParser(t *testing.T) {
+	parser := NewParser()
+
+	testExpected = "#"
+	testGot = parser.CommentPrefix
+	if testGot != testExpected {
+		t.Errorf("Expected Parser to have comment prefix [%s], but got [%s]", testExpected, testGot)
+	}
+
+	IgnoreComments = false
+	defer func() { IgnoreComments = true }()
+	parser = NewParser()
+
+	testExpected = ""
+	testGot = parser.CommentPrefix
+	if testGot != testExpected {
+		t.Errorf("Expected Parser to have comment prefix [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = []string{"2006-01-02"}
+	testGot = parser.DateLayouts
+	if !compareSlices(testGot.([]string), testExpected.([]string)) {
+		t.Errorf("Expected Parser to have date layouts %v, but got %v", testExpected, testGot)
+	}
+}
+
+func TestParserLoadFromFilename(t *testing.T) {
+	parser := &Parser{
+		CommentPrefix: "//",
+		DateLayouts:   []string{"02.01.2006", "2006-01-02"},
+		Lenient:       true,
+		TagKey: func(key string) bool {
+			return DefaultTagKey(strings.Replace(key, ".", "_", -1))
+		},
+	}
+
+	testTasklist, err := parser.LoadFromFilename(testInputParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = 4
+	testGot = len(testTasklist)
+	if testGot != testExpected {
+		t.Fatalf("Expected TaskList to contain %d tasks, but got %d", testExpected, testGot)
+	}
+
+	testExpected = time.Date(2012, 1, 30, 0, 0, 0, 0, time.UTC)
+	testGot = testTasklist[0].CreatedDate
+	if testGot != testExpected {
+		t.Errorf("Expected Task[1] to have created date [%v], but got [%v]", testExpected, testGot)
+	}
+	testExpected = time.Date(2014, 1, 12, 0, 0, 0, 0, time.UTC)
+	testGot = testTasklist[0].DueDate
+	if testGot != testExpected {
+		t.Errorf("Expected Task[1] to have due date [%v], but got [%v]", testExpected, testGot)
+	}
+
+	testExpected = "#1 Outline chapter 5"
+	testGot = testTasklist[1].Todo
+	if testGot != testExpected {
+		t.Errorf("Expected Task[2] to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "5"
+	testGot = testTasklist[2].AdditionalTags["meta.level"]
+	if testGot != testExpected {
+		t.Errorf("Expected Task[3] to have tag meta.level [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = true
+	testGot = testTasklist[3].Unparsed
+	if testGot != testExpected {
+		t.Errorf("Expected Task[4] to be unparsed, but got [%v]", testGot)
+	}
+
+	// The default parser does not know about any of these options
+	if _, err := LoadFromFilename(testInputParser); err == nil {
+		t.Errorf("Expected LoadFromFilename to fail")
+	}
+}
+
+func TestParserLoadDocumentFromFilename(t *testing.T) {
+	parser := &Parser{CommentPrefix: "//", DateLayouts: []string{"02.01.2006"}, Lenient: true}
+
+	doc, err := parser.LoadDocumentFromFilename(testInputParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = LINE_COMMENT
+	testGot = doc.Lines[0].Type
+	if testGot != testExpected {
+		t.Errorf("Expected Line[1] to be a comment, but got [%v]", testGot)
+	}
+	testExpected = LINE_TASK
+	testGot = doc.Lines[2].Type
+	if testGot != testExpected {
+		t.Errorf("Expected Line[3] to be a task, but got [%v]", testGot)
+	}
+}
+
+func TestParserDocumentString(t *testing.T) {
+	for _, parser := range []*Parser{
+		{DateLayouts: []string{"02.01.2006"}},
+		{DateLayouts: []string{"2006-01-02"}, Priority: PRIORITY_TAG},
+	} {
+		input := "01.02.2024 Call Mom\nx 2024-02-01 (A) Call Dad\n"
+		doc, err := parser.LoadDocumentFromReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if doc.Parser != parser {
+			t.Errorf("Expected Document to keep the Parser it was loaded with, but got [%v]", doc.Parser)
+		}
+
+		testExpected = input
+		testGot = doc.String()
+		if testGot != testExpected {
+			t.Errorf("Expected unchanged Document to be written as [%s], but got [%s]", testExpected, testGot)
+		}
+		testExpected = "01.02.2024 Call Mom"
+		testGot = doc.Lines[0].String()
+		if testGot != testExpected {
+			t.Errorf("Expected unchanged Line[1] to be written as [%s], but got [%s]", testExpected, testGot)
+		}
+	}
+
+	parser := &Parser{DateLayouts: []string{"02.01.2006"}}
+	doc, err := parser.LoadDocumentFromReader(strings.NewReader("01.02.2024 Call Mom\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc.Lines[0].Task.Hidden = true
+	testExpected = "01.02.2024 Call Mom h:1\n"
+	testGot = doc.String()
+	if testGot != testExpected {
+		t.Errorf("Expected changed Document to be written as [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestParserConcurrency(t *testing.T) {
+	parsers := []*Parser{
+		{DateLayouts: []string{"2006-01-02"}},
+		{DateLayouts: []string{"02.01.2006"}},
+	}
+	inputs := []string{"2014-01-30 Call Mom", "30.01.2014 Call Mom"}
+	expected := time.Date(2014, 1, 30, 0, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := range parsers {
+		wg.Add(1)
+		go func(parser *Parser, input string) {
+			defer wg.Done()
+			for n := 0; n < 100; n++ {
+				task, err := parser.ParseTask(input)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if !task.CreatedDate.Equal(expected) || task.Todo != "Call Mom" {
+					t.Errorf("Expected [%s] to be parsed with created date [%v], but got [%v]", input, expected, task)
+					return
+				}
+			}
+		}(parsers[i], inputs[i])
+	}
+	wg.Wait()
+}
+
+func TestParserClock(t *testing.T) {
+	parser := NewParser()
+	parser.Clock = todotxttest.FixedClock(time.Date(2014, 1, 20, 9, 0, 0, 0, time.Local))
+	tasklist, err := parser.LoadFromReader(strings.NewReader("Call Mom due:2014-01-15\nCall Dad due:2014-01-25\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "Call Mom"
+	testGot = todos(tasklist.Overdue())
+	if testGot != testExpected {
+		t.Errorf("Expected overdue tasks by the Clock of the Parser to be [%s], but got [%s]", testExpected, testGot)
+	}
+	if _, err := tasklist.Complete(1); err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "x 2014-01-20 Call Mom due:2014-01-15"
+	testGot = tasklist[0].String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be completed by the Clock of the Parser as [%s], but got [%s]", testExpected, testGot)
+	}
+
+	// A Clock given explicitly takes precedence
+	if !tasklist[1].IsOverdueWith(todotxttest.FixedClock(time.Date(2014, 1, 30, 9, 0, 0, 0, time.Local))) {
+		t.Errorf("Expected Task to be overdue by the given Clock, but it wasn't")
+	}
+}
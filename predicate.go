@@ -0,0 +1,190 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Predicate is a condition on tasks with a description, to be used with *TaskList.Filter():
+//
+//	predicate := And(ByProject("Work"), ByPriorityRange("A", "B"), Not(HasTag("waiting", "")))
+//	tasklist.Filter(predicate.Match)
+//	fmt.Println(predicate) // +Work and pri:A-B and not waiting:*
+//
+// Descriptions use the syntax of CompileQuery() where possible. The zero value matches all tasks.
+type Predicate struct {
+	description string
+	match       func(Task) bool
+	combinator  string // "and" or "or" for combined predicates, used to put them in parentheses when needed.
+}
+
+// NewPredicate creates a Predicate from any function, so that it can be combined with the others.
+func NewPredicate(description string, match func(Task) bool) Predicate {
+	return Predicate{description: description, match: match}
+}
+
+// Match returns true if the task fulfills the predicate.
+func (predicate Predicate) Match(task Task) bool {
+	return predicate.match == nil || predicate.match(task)
+}
+
+// String returns the description of the predicate.
+func (predicate Predicate) String() string {
+	return predicate.description
+}
+
+// And returns a Predicate matching tasks that fulfill all of the given predicates.
+func And(predicates ...Predicate) Predicate {
+	return combine("and", predicates, func(task Task) bool {
+		for _, predicate := range predicates {
+			if !predicate.Match(task) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// Or returns a Predicate matching tasks that fulfill any of the given predicates. Without predicates, no task matches.
+func Or(predicates ...Predicate) Predicate {
+	return combine("or", predicates, func(task Task) bool {
+		for _, predicate := range predicates {
+			if predicate.Match(task) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Not returns a Predicate matching tasks that do not fulfill the given predicate.
+// Combined predicates are described in parentheses, as 'not' binds stronger than 'and' and 'or'.
+func Not(predicate Predicate) Predicate {
+	return NewPredicate("not "+predicate.operand("not"), func(task Task) bool {
+		return !predicate.Match(task)
+	})
+}
+
+// combine creates a combined Predicate, describing it by joining the descriptions of the given predicates.
+func combine(combinator string, predicates []Predicate, match func(Task) bool) Predicate {
+	if len(predicates) == 1 {
+		return predicates[0]
+	}
+	descriptions := make([]string, len(predicates))
+	for i, predicate := range predicates {
+		descriptions[i] = predicate.operand(combinator)
+	}
+	return Predicate{description: strings.Join(descriptions, " "+combinator+" "), match: match, combinator: combinator}
+}
+
+// operand returns the description of the predicate as part of a combination, or negated by "not", in parentheses if it binds weaker.
+// 'and' binds stronger than 'or', 'not' binds strongest.
+func (predicate Predicate) operand(combinator string) string {
+	if predicate.combinator == "" || predicate.combinator == combinator || (predicate.combinator == "and" && combinator == "or") {
+		return predicate.description
+	}
+	return "(" + predicate.description + ")"
+}
+
+// ByProject returns a Predicate matching tasks with the given project, not taking case into account.
+func ByProject(project string) Predicate {
+	project = strings.TrimPrefix(project, "+")
+	return NewPredicate("+"+project, func(task Task) bool {
+		return containsFold(task.Projects, project)
+	})
+}
+
+// ByContext returns a Predicate matching tasks with the given context, not taking case into account.
+func ByContext(context string) Predicate {
+	context = strings.TrimPrefix(context, "@")
+	return NewPredicate("@"+context, func(task Task) bool {
+		return containsFold(task.Contexts, context)
+	})
+}
+
+// ByPriorityRange returns a Predicate matching tasks with a priority from 'from' to 'to', in either order.
+// "Priority at least B" is ByPriorityRange("A", "B"). Tasks without priority do not match.
+func ByPriorityRange(from, to string) Predicate {
+	if from > to {
+		from, to = to, from
+	}
+	description := "pri:" + from
+	if from != to {
+		description += "-" + to
+	}
+	return NewPredicate(description, func(task Task) bool {
+		return task.HasPriority() && task.Priority >= from && task.Priority <= to
+	})
+}
+
+// DueBefore returns a Predicate matching tasks that are due on a calendar day before the given date.
+// Tasks without due date do not match.
+func DueBefore(date time.Time) Predicate {
+	return dateComparison("due", "<", date)
+}
+
+// CompletedBetween returns a Predicate matching tasks that were completed on a calendar day from 'from' to 'to', both included.
+// Tasks without completed date do not match.
+func CompletedBetween(from, to time.Time) Predicate {
+	return And(dateComparison("completed", ">=", from), dateComparison("completed", "<=", to))
+}
+
+// HasTag returns a Predicate matching tasks whose additional tag with the given key has the given value, not taking case into account.
+// An empty value matches all tasks that have the tag.
+func HasTag(key, value string) Predicate {
+	description := key + ":" + value
+	if value == "" {
+		description = key + ":*"
+	}
+	return NewPredicate(description, func(task Task) bool {
+		tag, found := task.AdditionalTags[key]
+		return found && (value == "" || strings.EqualFold(tag, value))
+	})
+}
+
+// TextContains returns a Predicate matching tasks whose todo text contains the given text, not taking case into account.
+func TextContains(text string) Predicate {
+	lower := strings.ToLower(text)
+	quoted := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text) + `"`
+	return NewPredicate(quoted, func(task Task) bool {
+		return strings.Contains(strings.ToLower(task.Todo), lower)
+	})
+}
+
+// TextMatches returns a Predicate matching tasks whose todo text matches the regular expression.
+func TextMatches(expression *regexp.Regexp) Predicate {
+	return NewPredicate("/"+expression.String()+"/", func(task Task) bool {
+		return expression.MatchString(task.Todo)
+	})
+}
+
+// dateComparison returns a Predicate comparing the calendar day of a date field of the task with the calendar day of date.
+// Field is one of 'due', 'created', 'completed' or 't'. Tasks without the date do not match.
+func dateComparison(field, op string, date time.Time) Predicate {
+	date = dateOf(wallClock(date))
+	return NewPredicate(field+op+date.Format(DateLayout), func(task Task) bool {
+		var value time.Time
+		switch field {
+		case "due":
+			value = task.DueDate
+		case "created":
+			value = task.CreatedDate
+		case "completed":
+			if !task.HasCompletedDate() {
+				return false
+			}
+			value = task.CompletedDate
+		default:
+			value = task.ThresholdDate
+		}
+		if value.IsZero() {
+			return false
+		}
+		return compareOp(op, dateOf(wallClock(value)).Compare(date))
+	})
+}
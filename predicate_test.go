@@ -0,0 +1,101 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+func TestPredicates(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := func(month, day int) time.Time { return time.Date(2024, time.Month(month), day, 0, 0, 0, 0, time.UTC) }
+
+	for _, test := range []struct {
+		predicate   Predicate
+		description string
+		expected    string
+	}{
+		{ByProject("work"), "+work", "Send invoice to ACME|Prepare slides|Send invoice to Bob|Pay invoice"},
+		{ByContext("@Phone"), "@Phone", "Call Dad|Call Mom"},
+		{ByPriorityRange("B", "A"), "pri:A-B", "Send invoice to ACME|Prepare slides|Pay invoice"},
+		{ByPriorityRange("D", "D"), "pri:D", "Send invoice to Bob"},
+		{DueBefore(date(3, 14)), "due<2024-03-14", "Send invoice to Bob"},
+		{CompletedBetween(date(3, 1), date(3, 1)), "completed>=2024-03-01 and completed<=2024-03-01", "Pay invoice"},
+		{HasTag("pages", ""), "pages:*", "Call Mom|Read book"},
+		{HasTag("pages", "300"), "pages:300", "Read book"},
+		{TextContains(`Invoice`), `"Invoice"`, "Send invoice to ACME|Send invoice to Bob|Pay invoice"},
+		{TextContains(`say "hi"`), `"say \"hi\""`, ""},
+		{TextMatches(regexp.MustCompile(`^Call (Dad|Mom)$`)), "/^Call (Dad|Mom)$/", "Call Dad|Call Mom"},
+		{And(ByProject("Work"), Not(TextContains("invoice"))), `+Work and not "invoice"`, "Prepare slides"},
+		{Or(ByContext("phone"), And(ByProject("Work"), ByPriorityRange("A", "A"))), "@phone or +Work and pri:A", "Send invoice to ACME|Pay invoice|Call Dad|Call Mom"},
+		{And(Or(ByContext("phone"), HasTag("pages", "")), Not(Or(TextContains("Dad"), TextContains("Mom")))), `(@phone or pages:*) and not ("Dad" or "Mom")`, "Read book"},
+		{Not(And(ByProject("Work"), ByContext("phone"))), "not (+Work and @phone)", "Send invoice to ACME|Prepare slides|Send invoice to Bob|Pay invoice|Call Dad|Call Mom|Read book"},
+		{Not(And(ByProject("Work"), ByContext("office"))), "not (+Work and @office)", "Call Dad|Call Mom|Read book"},
+		{Or(), "", ""},
+		{And(), "", "Send invoice to ACME|Prepare slides|Send invoice to Bob|Pay invoice|Call Dad|Call Mom|Read book"},
+		{Predicate{}, "", "Send invoice to ACME|Prepare slides|Send invoice to Bob|Pay invoice|Call Dad|Call Mom|Read book"},
+	} {
+		testExpected = test.description
+		testGot = test.predicate.String()
+		if testGot != testExpected {
+			t.Errorf("Expected Predicate to be described as [%s], but got [%s]", testExpected, testGot)
+		}
+		testExpected = test.expected
+		testGot = todos(tasklist.Filter(test.predicate.Match))
+		if testGot != testExpected {
+			t.Errorf("Expected Predicate [%s] to match [%s], but got [%s]", test.predicate, testExpected, testGot)
+		}
+	}
+}
+
+func TestParseQueryString(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := todotxttest.FixedClock(time.Date(2024, 3, 10, 9, 0, 0, 0, Location))
+
+	for _, test := range []struct {
+		query       string
+		description string
+		expected    string
+	}{
+		{
+			`+Work @office pri:A-C due<=+7d not done "invoice" or (@phone and created>2024-01-01)`,
+			`+Work and @office and pri:A-C and due<=2024-03-17 and not done and "invoice" or @phone and created>2024-01-01`,
+			"Send invoice to ACME|Call Dad",
+		},
+		{`not (+Work and @office) @phone`, `not (+Work and @office) and @phone`, "Call Dad|Call Mom"},
+	} {
+		predicate, err := ParseQueryWith(test.query, clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testExpected = test.description
+		testGot = predicate.String()
+		if testGot != testExpected {
+			t.Errorf("Expected query to be described as [%s], but got [%s]", testExpected, testGot)
+		}
+
+		// The description is a query again, giving the same result
+		reparsed, err := ParseQueryWith(predicate.String(), clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testExpected = todos(tasklist.Filter(predicate.Match))
+		testGot = todos(tasklist.Filter(reparsed.Match))
+		if testGot != testExpected || testGot != test.expected {
+			t.Errorf("Expected reparsed query [%s] to match [%s], but got [%s]", predicate, testExpected, testGot)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+// PriorityPolicy decides what happens to the priority of a task when it is completed, see Parser.Priority.
+//
+// The todo.txt format recommends to either drop the priority of completed tasks, or keep it in a 'pri:' tag:
+//
+//	"x 2014-01-12 Call Dad pri:A" instead of "x 2014-01-12 (A) Call Dad"
+//
+// A Parser reads both styles of completed tasks into the same Task values, without dropping anything:
+// a completed task with either a priority prefix or a 'pri:' tag is read with a 'pri:' tag for PRIORITY_TAG,
+// and with a priority prefix otherwise. Completed tasks with both are read as they are.
+type PriorityPolicy int
+
+// Policies for the priority of completed tasks.
+const (
+	PRIORITY_KEEP PriorityPolicy = iota // Completed tasks keep their priority prefix.
+	PRIORITY_DROP                       // The priority is removed when completing a task, both as prefix and as 'pri:' tag.
+	PRIORITY_TAG                        // The priority is moved into a 'pri:' tag when completing a task, and back when reopening it.
+)
+
+// applyTo changes the priority of a completed task according to the policy.
+// Tasks that are not completed are not changed.
+func (policy PriorityPolicy) applyTo(task *Task) {
+	if !task.Completed {
+		return
+	}
+	switch policy {
+	case PRIORITY_DROP:
+		task.Priority = ""
+		if isPriorityLetter(task.AdditionalTags["pri"]) {
+			delete(task.AdditionalTags, "pri")
+		}
+	case PRIORITY_TAG:
+		if task.HasPriority() {
+			if task.AdditionalTags == nil {
+				task.AdditionalTags = make(map[string]string)
+			}
+			task.AdditionalTags["pri"] = task.Priority
+			task.Priority = ""
+		}
+	}
+}
+
+// normalize reads a completed task in the shape the policy gives completed tasks, see PriorityPolicy.
+// A priority is only moved between prefix and 'pri:' tag if the task has just one of them, so nothing is dropped.
+func (policy PriorityPolicy) normalize(task *Task) {
+	if !task.Completed {
+		return
+	}
+	if policy == PRIORITY_TAG {
+		if _, found := task.AdditionalTags["pri"]; !found {
+			policy.applyTo(task)
+		}
+		return
+	}
+	task.restorePriority()
+}
+
+// restorePriority moves the priority of a reopened or recurring task back from its 'pri:' tag.
+func (task *Task) restorePriority() {
+	if !task.HasPriority() && isPriorityLetter(task.AdditionalTags["pri"]) {
+		task.Priority = task.AdditionalTags["pri"]
+		delete(task.AdditionalTags, "pri")
+	}
+}
+
+// isPriorityLetter returns true if text is a single priority letter, 'A' to 'Z'.
+func isPriorityLetter(text string) bool {
+	return len(text) == 1 && text[0] >= 'A' && text[0] <= 'Z'
+}
@@ -0,0 +1,142 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+func TestTaskCompletePriorityPolicy(t *testing.T) {
+	clock := todotxttest.FixedClock(time.Date(2014, 1, 12, 9, 0, 0, 0, time.UTC))
+
+	for policy, expected := range map[PriorityPolicy]string{
+		PRIORITY_KEEP: "x 2014-01-12 (A) Call Dad @Phone",
+		PRIORITY_DROP: "x 2014-01-12 Call Dad @Phone",
+		PRIORITY_TAG:  "x 2014-01-12 Call Dad @Phone pri:A",
+	} {
+		parser := NewParser()
+		parser.Priority = policy
+		task, err := parser.ParseTask("(A) Call Dad @Phone")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		task.CompleteWith(clock)
+		testExpected = expected
+		testGot = task.String()
+		if testGot != testExpected {
+			t.Errorf("Expected completed Task to be [%s], but got [%s]", testExpected, testGot)
+		}
+
+		task.Reopen()
+		testExpected = "(A) Call Dad @Phone"
+		if policy == PRIORITY_DROP {
+			testExpected = "Call Dad @Phone"
+		}
+		testGot = task.String()
+		if testGot != testExpected {
+			t.Errorf("Expected reopened Task to be [%s], but got [%s]", testExpected, testGot)
+		}
+	}
+}
+
+func TestParserPriorityPolicy(t *testing.T) {
+	input := "x 2014-01-12 (A) Call Dad @Phone\nx 2014-01-12 Call Dad @Phone pri:A\n(B) Call Mom pri:A\n"
+
+	for policy, expected := range map[PriorityPolicy]string{
+		PRIORITY_KEEP: "x 2014-01-12 (A) Call Dad @Phone\nx 2014-01-12 (A) Call Dad @Phone\n(B) Call Mom pri:A\n",
+		PRIORITY_DROP: "x 2014-01-12 (A) Call Dad @Phone\nx 2014-01-12 (A) Call Dad @Phone\n(B) Call Mom pri:A\n",
+		PRIORITY_TAG:  "x 2014-01-12 Call Dad @Phone pri:A\nx 2014-01-12 Call Dad @Phone pri:A\n(B) Call Mom pri:A\n",
+	} {
+		parser := NewParser()
+		parser.Priority = policy
+		tasklist, err := parser.LoadFromReader(strings.NewReader(input))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		testExpected = expected
+		testGot = tasklist.String()
+		if testGot != testExpected {
+			t.Errorf("Expected TaskList with policy [%d] to be [%s], but got [%s]", policy, testExpected, testGot)
+		}
+		if !tasklist[0].equals(&tasklist[1]) {
+			t.Errorf("Expected both styles of completed tasks to give the same Task with policy [%d], but got [%v] and [%v]", policy, tasklist[0], tasklist[1])
+		}
+	}
+}
+
+func TestParserPriorityPolicyLossless(t *testing.T) {
+	for _, policy := range []PriorityPolicy{PRIORITY_KEEP, PRIORITY_DROP, PRIORITY_TAG} {
+		parser := NewParser()
+		parser.Priority = policy
+		task, err := parser.ParseTask("x 2024-01-01 (A) Done thing pri:B")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := task.SetTodo("Done thing 2"); err != nil {
+			t.Fatal(err)
+		}
+
+		testExpected = "x 2024-01-01 (A) Done thing 2 pri:B"
+		testGot = task.PreservedString()
+		if testGot != testExpected {
+			t.Errorf("Expected Task with policy [%d] to be [%s], but got [%s]", policy, testExpected, testGot)
+		}
+	}
+}
+
+func TestParserPriorityPolicyPreserved(t *testing.T) {
+	parser := NewParser()
+	parser.Priority = PRIORITY_TAG
+	writer := NewWriter()
+	writer.SortTokens = false
+	writer.Parser = parser
+
+	task, err := parser.ParseTask("x 2014-01-12 (A) Call Dad @Phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "x 2014-01-12 (A) Call Dad @Phone"
+	testGot = writer.Format(*task)
+	if testGot != testExpected {
+		t.Errorf("Expected unmodified Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.Reopen()
+	testExpected = "(A) Call Dad @Phone"
+	testGot = writer.Format(*task)
+	if testGot != testExpected {
+		t.Errorf("Expected reopened Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskListCompletePriorityPolicy(t *testing.T) {
+	parser := NewParser()
+	parser.Priority = PRIORITY_TAG
+	tasklist, err := parser.LoadFromReader(strings.NewReader("(A) Water plants rec:1w due:2014-01-08\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := tasklist.CompleteWith(1, todotxttest.FixedClock(time.Date(2014, 1, 10, 9, 0, 0, 0, time.Local)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "x 2014-01-10 Water plants pri:A rec:1w due:2014-01-08"
+	testGot = tasklist[0].String()
+	if testGot != testExpected {
+		t.Errorf("Expected completed Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = "(A) 2014-01-10 Water plants rec:1w due:2014-01-17"
+	testGot = next.String()
+	if testGot != testExpected {
+		t.Errorf("Expected next occurrence to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
@@ -0,0 +1,409 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QuerySyntaxError describes a problem with a query, see CompileQuery().
+type QuerySyntaxError struct {
+	Query   string // Text of the query.
+	Column  int    // Column of the offending part of the query, starting at 1.
+	Message string // Description of the problem.
+}
+
+// Error returns a description of the error, including its position.
+func (err *QuerySyntaxError) Error() string {
+	return fmt.Sprintf("column %d: %s", err.Column, err.Message)
+}
+
+// queryToken is a word, a quoted text or a parenthesis of a query.
+type queryToken struct {
+	text   string // Text of the token, without quotes for quoted text.
+	start  int    // Byte offset in the query.
+	quoted bool
+}
+
+// query holds the state of compiling a single query.
+type query struct {
+	text   string
+	tokens []queryToken
+	pos    int       // Index of the next token.
+	today  time.Time // Current calendar day, for relative dates.
+}
+
+// CompileQuery compiles a textual query into a predicate for *TaskList.Filter(), for example:
+//
+//	+Work @office pri:A-C due<=+7d not done "invoice" or (@phone and created>2024-01-01)
+//
+// A query consists of terms, which are combined with 'and', 'or' and 'not', and grouped with parentheses.
+// Terms next to each other must all match, 'and' binds stronger than 'or'. Keywords are not case sensitive.
+//
+//	+Project, @context   task has the project or context, not case sensitive
+//	pri:A, pri:A-C       task has the priority, or one within the range
+//	done                 task is completed
+//	due, created,        compared with '<', '<=', '>', '>=', '=' or ':' against a date in DateLayout,
+//	completed, t         'today', 'tomorrow', 'yesterday', or a number of days, weeks, months or years from today: '+7d', '-2w'
+//	                     Tasks without the date do not match.
+//	key:value, key:*     additional tag has the value, or exists. Values are compared by '<', '<=', '>' and '>=' as their registered
+//	                     duration or date type, see RegisterTagType(), otherwise as numbers if possible
+//	"some text", word    todo text contains the text, not case sensitive
+//
+// Relative dates are resolved when compiling, using the current calendar day of DefaultClock in Location.
+// Returns a *QuerySyntaxError if the query can not be compiled.
+func CompileQuery(text string) (func(Task) bool, error) {
+	return CompileQueryWith(text, DefaultClock)
+}
+
+// CompileQueryWith works like CompileQuery(), resolving relative dates with the current calendar day of the given Clock.
+func CompileQueryWith(text string, clock Clock) (func(Task) bool, error) {
+	predicate, err := ParseQueryWith(text, clock)
+	if err != nil {
+		return nil, err
+	}
+	return predicate.Match, nil
+}
+
+// ParseQuery works like CompileQuery(), but returns a Predicate, which can be combined with others and printed.
+// The description of the Predicate is the normalized query, with relative dates resolved.
+func ParseQuery(text string) (Predicate, error) {
+	return ParseQueryWith(text, DefaultClock)
+}
+
+// ParseQueryWith works like ParseQuery(), resolving relative dates with the current calendar day of the given Clock.
+func ParseQueryWith(text string, clock Clock) (Predicate, error) {
+	q := &query{text: text, today: dateOf(now(clock, nil))}
+	if err := q.tokenize(); err != nil {
+		return Predicate{}, err
+	}
+	if len(q.tokens) == 0 {
+		return Predicate{}, nil
+	}
+	predicate, err := q.parseOr()
+	if err != nil {
+		return Predicate{}, err
+	}
+	if q.pos < len(q.tokens) {
+		return Predicate{}, q.errorAt(q.tokens[q.pos].start, fmt.Sprintf("unexpected %q", q.tokens[q.pos].text))
+	}
+	return predicate, nil
+}
+
+// Query returns a new TaskList with all tasks matching the query, see CompileQuery() and *TaskList.Filter().
+// The original TaskList is not modified.
+func (tasklist *TaskList) Query(text string) (*TaskList, error) {
+	predicate, err := CompileQuery(text)
+	if err != nil {
+		return nil, err
+	}
+	return tasklist.Filter(predicate), nil
+}
+
+func (q *query) errorAt(offset int, message string) error {
+	return &QuerySyntaxError{Query: q.text, Column: offset + 1, Message: message}
+}
+
+// tokenize splits the query into words, quoted texts and parentheses.
+func (q *query) tokenize() error {
+	for i := 0; i < len(q.text); {
+		switch c := q.text[i]; {
+		case isSpace(c):
+			i++
+		case c == '(' || c == ')':
+			q.tokens = append(q.tokens, queryToken{text: q.text[i : i+1], start: i})
+			i++
+		case c == '"':
+			var text strings.Builder
+			end := i + 1
+			for ; end < len(q.text) && q.text[end] != '"'; end++ {
+				if q.text[end] == '\\' && end+1 < len(q.text) {
+					end++
+				}
+				text.WriteByte(q.text[end])
+			}
+			if end >= len(q.text) {
+				return q.errorAt(i, "missing closing quote")
+			}
+			q.tokens = append(q.tokens, queryToken{text: text.String(), start: i, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(q.text) && !isSpace(q.text[end]) && q.text[end] != '(' && q.text[end] != ')' && q.text[end] != '"' {
+				end++
+			}
+			q.tokens = append(q.tokens, queryToken{text: q.text[i:end], start: i})
+			i = end
+		}
+	}
+	return nil
+}
+
+// keyword returns true if the next token is the given keyword.
+func (q *query) keyword(keyword string) bool {
+	return q.pos < len(q.tokens) && !q.tokens[q.pos].quoted && strings.EqualFold(q.tokens[q.pos].text, keyword)
+}
+
+// closing returns true if the next token is a closing parenthesis, which is not quoted.
+func (q *query) closing() bool {
+	return q.pos < len(q.tokens) && !q.tokens[q.pos].quoted && q.tokens[q.pos].text == ")"
+}
+
+// parseOr parses terms combined with 'or'.
+func (q *query) parseOr() (Predicate, error) {
+	left, err := q.parseAnd()
+	if err != nil {
+		return Predicate{}, err
+	}
+	for q.keyword("or") {
+		q.pos++
+		right, err := q.parseAnd()
+		if err != nil {
+			return Predicate{}, err
+		}
+		left = Or(left, right)
+	}
+	return left, nil
+}
+
+// parseAnd parses terms combined with 'and', or just written next to each other.
+func (q *query) parseAnd() (Predicate, error) {
+	left, err := q.parseNot()
+	if err != nil {
+		return Predicate{}, err
+	}
+	for q.pos < len(q.tokens) && !q.keyword("or") && !q.closing() {
+		if q.keyword("and") {
+			q.pos++
+		}
+		right, err := q.parseNot()
+		if err != nil {
+			return Predicate{}, err
+		}
+		left = And(left, right)
+	}
+	return left, nil
+}
+
+// parseNot parses a term, which may be negated by 'not'.
+func (q *query) parseNot() (Predicate, error) {
+	if q.keyword("not") {
+		q.pos++
+		predicate, err := q.parseNot()
+		if err != nil {
+			return Predicate{}, err
+		}
+		return Not(predicate), nil
+	}
+	return q.parseTerm()
+}
+
+// parseTerm parses a single term or a group in parentheses.
+func (q *query) parseTerm() (Predicate, error) {
+	if q.pos >= len(q.tokens) {
+		return Predicate{}, q.errorAt(len(q.text), "missing term at end of query")
+	}
+	token := q.tokens[q.pos]
+	q.pos++
+
+	if token.quoted {
+		return TextContains(token.text), nil
+	}
+	switch {
+	case token.text == "(":
+		predicate, err := q.parseOr()
+		if err != nil {
+			return Predicate{}, err
+		}
+		if !q.closing() {
+			return Predicate{}, q.errorAt(token.start, "missing closing parenthesis")
+		}
+		q.pos++
+		return predicate, nil
+	case token.text == ")":
+		return Predicate{}, q.errorAt(token.start, "unexpected closing parenthesis")
+	case strings.EqualFold(token.text, "and") || strings.EqualFold(token.text, "or"):
+		return Predicate{}, q.errorAt(token.start, fmt.Sprintf("missing term before %q", token.text))
+	case strings.EqualFold(token.text, "done"):
+		return NewPredicate("done", func(t Task) bool { return t.Completed }), nil
+	case len(token.text) > 1 && token.text[0] == '+':
+		return ByProject(token.text[1:]), nil
+	case len(token.text) > 1 && token.text[0] == '@':
+		return ByContext(token.text[1:]), nil
+	}
+	return q.parseComparison(token)
+}
+
+// parseComparison parses a 'field<op>value' term, or free text if the token is no comparison.
+func (q *query) parseComparison(token queryToken) (Predicate, error) {
+	i := strings.IndexAny(token.text, ":<>=")
+	if i <= 0 || !DefaultTagKey(token.text[:i]) || isLink(token.text) {
+		return TextContains(token.text), nil
+	}
+	field := strings.ToLower(token.text[:i])
+	op := token.text[i : i+1]
+	if i+1 < len(token.text) && token.text[i+1] == '=' && (op == "<" || op == ">") {
+		op += "="
+	}
+	value := token.text[i+len(op):]
+	valueStart := token.start + i + len(op)
+	if value == "" {
+		return Predicate{}, q.errorAt(valueStart, fmt.Sprintf("missing value after %q", token.text))
+	}
+
+	switch field {
+	case "pri", "priority":
+		if op != ":" && op != "=" {
+			return Predicate{}, q.errorAt(token.start+i, fmt.Sprintf("priorities can only be compared with ':', not %q", op))
+		}
+		return q.priorityPredicate(value, valueStart)
+	case "due", "created", "completed", "t", "threshold":
+		date, err := q.parseDate(value, valueStart)
+		if err != nil {
+			return Predicate{}, err
+		}
+		if op == ":" {
+			op = "="
+		}
+		if field == "threshold" {
+			field = "t"
+		}
+		return dateComparison(field, op, date), nil
+	}
+	return q.tagComparison(token.text[:i], op, value, valueStart)
+}
+
+// priorityPredicate parses 'A' or a range 'A-C' of priorities.
+func (q *query) priorityPredicate(value string, start int) (Predicate, error) {
+	from, to := value, value
+	if len(value) == 3 && value[1] == '-' {
+		from, to = value[:1], value[2:]
+	}
+	if !isPriorityLetter(from) || !isPriorityLetter(to) {
+		return Predicate{}, q.errorAt(start, fmt.Sprintf("invalid priority %q, expected a letter 'A' to 'Z' or a range like 'A-C'", value))
+	}
+	return ByPriorityRange(from, to), nil
+}
+
+// parseDate parses an absolute date, 'today', 'tomorrow', 'yesterday', or a relative date like '+7d'.
+func (q *query) parseDate(value string, start int) (time.Time, error) {
+	switch strings.ToLower(value) {
+	case "today":
+		return q.today, nil
+	case "tomorrow":
+		return q.today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return q.today.AddDate(0, 0, -1), nil
+	}
+	if value[0] == '+' || value[0] == '-' {
+		rec, err := ParseRecurrence(value[1:])
+		if err != nil || rec.Strict {
+			return time.Time{}, q.errorAt(start, fmt.Sprintf("invalid relative date %q, expected a number of days, weeks, months or years like '+7d'", value))
+		}
+		if value[0] == '-' {
+			rec.Interval = -rec.Interval
+		}
+		return rec.Next(q.today), nil
+	}
+	date, err := time.Parse(DateLayout, value)
+	if err != nil {
+		return time.Time{}, q.errorAt(start, fmt.Sprintf("invalid date %q, expected %s, 'today' or a relative date like '+7d'", value, DateLayout))
+	}
+	return date, nil
+}
+
+// tagComparison compares an additional tag of the task with value.
+// Keys with a registered duration or date type are compared as such, see RegisterTagType(), other values as numbers if both can be read as such.
+func (q *query) tagComparison(key, op, value string, start int) (Predicate, error) {
+	if op == ":" || op == "=" {
+		if value == "*" {
+			return HasTag(key, ""), nil
+		}
+		return HasTag(key, value), nil
+	}
+
+	switch tagType, _ := RegisteredTagType(key); tagType {
+	case TAG_DURATION:
+		duration, err := parseDuration(value)
+		if err != nil {
+			return Predicate{}, q.errorAt(start, fmt.Sprintf("invalid duration %q, expected a duration like '1h30m' or '2d'", value))
+		}
+		return NewPredicate(key+op+value, func(t Task) bool {
+			tagDuration, err := t.TagDuration(key)
+			if err != nil {
+				return false
+			}
+			switch {
+			case tagDuration < duration:
+				return compareOp(op, -1)
+			case tagDuration > duration:
+				return compareOp(op, 1)
+			}
+			return compareOp(op, 0)
+		}), nil
+	case TAG_DATE:
+		date, err := q.parseDate(value, start)
+		if err != nil {
+			return Predicate{}, err
+		}
+		return NewPredicate(key+op+date.Format(DateLayout), func(t Task) bool {
+			tag, found := t.AdditionalTags[key]
+			if !found {
+				return false
+			}
+			tagDate, err := t.parser().parseDate(tag)
+			if err != nil {
+				return false
+			}
+			return compareOp(op, tagDate.Compare(date))
+		}), nil
+	}
+
+	number, numberErr := strconv.ParseFloat(value, 64)
+	return NewPredicate(key+op+value, func(t Task) bool {
+		tag, found := t.AdditionalTags[key]
+		if !found {
+			return false
+		}
+		if tagNumber, err := strconv.ParseFloat(tag, 64); err == nil && numberErr == nil {
+			switch {
+			case tagNumber < number:
+				return compareOp(op, -1)
+			case tagNumber > number:
+				return compareOp(op, 1)
+			}
+			return compareOp(op, 0)
+		}
+		return compareOp(op, strings.Compare(tag, value))
+	}), nil
+}
+
+// compareOp returns true if the result of a comparison, -1, 0 or +1, satisfies the operator.
+func compareOp(op string, result int) bool {
+	switch op {
+	case "<":
+		return result < 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case ">=":
+		return result >= 0
+	}
+	return result == 0
+}
+
+// containsFold returns true if slice contains s, not taking case into account.
+func containsFold(slice []string, s string) bool {
+	for _, element := range slice {
+		if strings.EqualFold(element, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+var testInputQuery = `(A) 2024-01-10 Send invoice to ACME +Work @Office due:2024-03-14
+(B) 2024-01-20 Prepare slides +Work @office due:2024-03-30
+(D) 2023-12-01 Send invoice to Bob +Work @Office due:2024-03-08
+x 2024-03-01 (A) 2024-02-01 Pay invoice +Work @Office
+2024-02-15 Call Dad @Phone
+2023-11-01 Call Mom @phone pages:12
+Read book pages:300 t:2024-03-20
+`
+
+func testQuery(t *testing.T, text string) string {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+	predicate, err := CompileQueryWith(text, todotxttest.FixedClock(time.Date(2024, 3, 10, 9, 0, 0, 0, Location)))
+	if err != nil {
+		t.Fatalf("Expected query [%s] to compile, but got error [%v]", text, err)
+	}
+	return todos(tasklist.Filter(predicate))
+}
+
+func TestCompileQuery(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		expected string
+	}{
+		{`+Work @office pri:A-C due<=+7d not done "invoice" or (@phone and created>2024-01-01)`, "Send invoice to ACME|Call Dad"},
+		{`+work`, "Send invoice to ACME|Prepare slides|Send invoice to Bob|Pay invoice"},
+		{`pri:A`, "Send invoice to ACME|Pay invoice"},
+		{`pri:C-A not done`, "Send invoice to ACME|Prepare slides"},
+		{`done`, "Pay invoice"},
+		{`completed>=2024-03-01`, "Pay invoice"},
+		{`due<today`, "Send invoice to Bob"},
+		{`due:+4d`, "Send invoice to ACME"},
+		{`due>=tomorrow AND due<+3w`, "Send invoice to ACME|Prepare slides"},
+		{`created<-3m`, "Send invoice to Bob|Call Mom"},
+		{`t>today`, "Read book"},
+		{`pages:*`, "Call Mom|Read book"},
+		{`pages>100`, "Read book"},
+		{`pages<=12`, "Call Mom"},
+		{`call not @PHONE`, ""},
+		{`invoice not (to or pay)`, ""},
+		{`"invoice to" bob`, "Send invoice to Bob"},
+		{`not not done`, "Pay invoice"},
+		{`call ")"`, ""},
+		{`(call or ")")`, "Call Dad|Call Mom"},
+		{``, "Send invoice to ACME|Prepare slides|Send invoice to Bob|Pay invoice|Call Dad|Call Mom|Read book"},
+	} {
+		testExpected = test.expected
+		testGot = testQuery(t, test.query)
+		if testGot != testExpected {
+			t.Errorf("Expected query [%s] to match [%s], but got [%s]", test.query, testExpected, testGot)
+		}
+	}
+}
+
+func TestCompileQueryTagTypes(t *testing.T) {
+	RegisterTagType("est", TAG_DURATION)
+	RegisterTagType("review", TAG_DATE)
+	defer UnregisterTagType("est")
+	defer UnregisterTagType("review")
+
+	tasklist, err := LoadFromReader(strings.NewReader("Write report est:90m review:2024-03-08\nCall Mom est:15m review:2024-03-12\nFix (old) bug est:2d\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		query    string
+		expected string
+	}{
+		{`est>1h`, "Write report|Fix (old) bug"},
+		{`est<=90m`, "Write report|Call Mom"},
+		{`review<today`, "Write report"},
+		{`review>=2024-03-10`, "Call Mom"},
+		{`bug ")"`, "Fix (old) bug"},
+	} {
+		predicate, err := CompileQueryWith(test.query, todotxttest.FixedClock(time.Date(2024, 3, 10, 9, 0, 0, 0, Location)))
+		if err != nil {
+			t.Fatalf("Expected query [%s] to compile, but got error [%v]", test.query, err)
+		}
+		testExpected = test.expected
+		testGot = todos(tasklist.Filter(predicate))
+		if testGot != testExpected {
+			t.Errorf("Expected query [%s] to match [%s], but got [%s]", test.query, testExpected, testGot)
+		}
+	}
+
+	if _, err := CompileQuery("est>soon"); err == nil || err.Error() != `column 5: invalid duration "soon", expected a duration like '1h30m' or '2d'` {
+		t.Errorf("Expected invalid duration error, but got [%v]", err)
+	}
+}
+
+func TestCompileQuerySyntaxErrors(t *testing.T) {
+	for _, test := range []struct {
+		query    string
+		expected string
+	}{
+		{`+Work (@office or @phone`, `column 7: missing closing parenthesis`},
+		{`+Work @office)`, `column 14: unexpected ")"`},
+		{`+Work or`, `column 9: missing term at end of query`},
+		{`or +Work`, `column 1: missing term before "or"`},
+		{`+Work and or @phone`, `column 11: missing term before "or"`},
+		{`pri:a`, `column 5: invalid priority "a", expected a letter 'A' to 'Z' or a range like 'A-C'`},
+		{`pri<B`, `column 4: priorities can only be compared with ':', not "<"`},
+		{`due<=2024-13-01`, `column 6: invalid date "2024-13-01", expected 2006-01-02, 'today' or a relative date like '+7d'`},
+		{`due>+7x`, `column 5: invalid relative date "+7x", expected a number of days, weeks, months or years like '+7d'`},
+		{`@office due:`, `column 13: missing value after "due:"`},
+		{`"invoice`, `column 1: missing closing quote`},
+	} {
+		_, err := CompileQuery(test.query)
+		var syntaxError *QuerySyntaxError
+		if !errors.As(err, &syntaxError) {
+			t.Errorf("Expected *QuerySyntaxError for query [%s], but got [%v]", test.query, err)
+			continue
+		}
+		testExpected = test.expected
+		testGot = err.Error()
+		if testGot != testExpected {
+			t.Errorf("Expected error for query [%s] to be [%s], but got [%s]", test.query, testExpected, testGot)
+		}
+	}
+}
+
+func TestTaskListQuery(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(testInputQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := tasklist.Query("@phone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Call Dad|Call Mom"
+	testGot = todos(result)
+	if testGot != testExpected {
+		t.Errorf("Expected [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if _, err := tasklist.Query("(@phone"); err == nil {
+		t.Errorf("Expected Query to fail because of missing parenthesis, but it didn't!")
+	}
+}
+
+func FuzzCompileQuery(f *testing.F) {
+	f.Add(`+Work @office pri:A-C due<=+7d not done "invoice" or (@phone and created>2024-01-01)`)
+	f.Add(`(not (a or "b\"c") and key>=1.5)`)
+	tasklist, err := LoadFromReader(strings.NewReader(testInputQuery))
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Fuzz(func(t *testing.T, text string) {
+		predicate, err := CompileQuery(text)
+		if err != nil {
+			var syntaxError *QuerySyntaxError
+			if !errors.As(err, &syntaxError) || syntaxError.Column < 1 || syntaxError.Column > len(text)+1 {
+				t.Errorf("Expected *QuerySyntaxError within query [%s], but got [%v]", text, err)
+			}
+			return
+		}
+		result := todos(tasklist.Filter(predicate))
+
+		// The description of the parsed query is a query again, giving the same result
+		parsed, err := ParseQuery(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reparsed, err := CompileQuery(parsed.String())
+		if err != nil {
+			t.Fatalf("Expected description [%s] of query [%s] to compile, but got error [%v]", parsed, text, err)
+		}
+		if todos(tasklist.Filter(reparsed)) != result {
+			t.Errorf("Expected description [%s] of query [%s] to match [%s], but got [%s]", parsed, text, result, todos(tasklist.Filter(reparsed)))
+		}
+	})
+}
@@ -0,0 +1,199 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"strconv"
+	"time"
+)
+
+// RecurrenceUnit is the unit of a Recurrence interval.
+type RecurrenceUnit byte
+
+// Units of a Recurrence interval, as used in 'rec:' tags.
+const (
+	RECURRENCE_DAY   RecurrenceUnit = 'd'
+	RECURRENCE_WEEK  RecurrenceUnit = 'w'
+	RECURRENCE_MONTH RecurrenceUnit = 'm'
+	RECURRENCE_YEAR  RecurrenceUnit = 'y'
+)
+
+// Recurrence describes how often a task repeats, as read from its 'rec:' tag.
+//
+// For example:
+//
+//	"rec:1w"  - one week after the task was completed
+//	"rec:+1m" - one month after the task was due (strict mode)
+type Recurrence struct {
+	Strict   bool           // Next occurrence is counted from the due date, instead of the completion date.
+	Interval int            // Number of units between occurrences, at least 1.
+	Unit     RecurrenceUnit // Unit of the interval.
+}
+
+// ParseRecurrence parses the value of a 'rec:' tag: an optional '+', a number and one of the units 'd', 'w', 'm' or 'y'.
+//
+// Returns ErrInvalidRecurrence if text is not a valid recurrence.
+func ParseRecurrence(text string) (Recurrence, error) {
+	var rec Recurrence
+	if len(text) > 0 && text[0] == '+' {
+		rec.Strict = true
+		text = text[1:]
+	}
+	if len(text) < 2 {
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+
+	interval, err := strconv.Atoi(text[:len(text)-1])
+	if err != nil || interval < 1 || !isDigit(text[0]) {
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+	rec.Interval = interval
+
+	switch unit := RecurrenceUnit(text[len(text)-1]); unit {
+	case RECURRENCE_DAY, RECURRENCE_WEEK, RECURRENCE_MONTH, RECURRENCE_YEAR:
+		rec.Unit = unit
+	default:
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+	return rec, nil
+}
+
+// String returns the recurrence in 'rec:' tag format.
+func (rec Recurrence) String() string {
+	text := strconv.Itoa(rec.Interval) + string(rec.Unit)
+	if rec.Strict {
+		return "+" + text
+	}
+	return text
+}
+
+// Next returns the date one interval after date.
+//
+// Monthly and yearly steps never overflow into the following month,
+// a date past the end of the target month is moved to its last day (Jan 31 + 1m = Feb 28).
+func (rec Recurrence) Next(date time.Time) time.Time {
+	switch rec.Unit {
+	case RECURRENCE_WEEK:
+		return date.AddDate(0, 0, 7*rec.Interval)
+	case RECURRENCE_MONTH:
+		return addMonths(date, rec.Interval)
+	case RECURRENCE_YEAR:
+		return addMonths(date, 12*rec.Interval)
+	}
+	return date.AddDate(0, 0, rec.Interval)
+}
+
+// addMonths adds months to date, clamping the day to the last day of the target month.
+func addMonths(date time.Time, months int) time.Time {
+	year, month, day := date.Date()
+	first := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, date.Hour(), date.Minute(), date.Second(), date.Nanosecond(), date.Location())
+}
+
+// HasRecurrence returns true if the task has a 'rec:' tag.
+func (task *Task) HasRecurrence() bool {
+	_, found := task.AdditionalTags["rec"]
+	return found
+}
+
+// Recurrence returns the parsed 'rec:' tag of the task.
+//
+// Returns ErrInvalidRecurrence if the task has no 'rec:' tag or it can not be parsed.
+func (task *Task) Recurrence() (Recurrence, error) {
+	text, found := task.AdditionalTags["rec"]
+	if !found {
+		return Recurrence{}, ErrInvalidRecurrence
+	}
+	return ParseRecurrence(text)
+}
+
+// NextOccurrence returns a new task for the next occurrence of a recurring task, which was completed at the given time.
+//
+// The new task is not completed and its created date is set to the completion date.
+// A priority kept in a 'pri:' tag is restored, see PRIORITY_TAG, and the 'uid:' tag is not copied.
+// Due and threshold dates are shifted by one interval, counted from the completion date,
+// or from the due date in strict mode. The distance between threshold and due date stays the same,
+// and so does the time of day of the due date.
+// If the task has neither of them, the new task is due one interval after the completion date.
+//
+// Returns ErrInvalidRecurrence if the task has no valid 'rec:' tag.
+func (task *Task) NextOccurrence(completed time.Time) (*Task, error) {
+	rec, err := task.Recurrence()
+	if err != nil {
+		return nil, err
+	}
+	completedDate := dateOf(wallClock(completed))
+
+	next := task.copy()
+	next.Id = 0
+	next.Original = ""
+	next.Completed = false
+	next.CompletedDate = time.Time{}
+	next.CreatedDate = completedDate
+	next.restorePriority()
+	delete(next.AdditionalTags, "uid") // the next occurrence is a task of its own
+
+	switch {
+	case rec.Strict && task.HasDueDate():
+		next.DueDate = rec.Next(task.DueDate)
+		if task.HasThresholdDate() {
+			next.ThresholdDate = rec.Next(task.ThresholdDate)
+		}
+	case rec.Strict && task.HasThresholdDate():
+		next.ThresholdDate = rec.Next(task.ThresholdDate)
+	case task.HasDueDate():
+		next.DueDate = rec.Next(completedDate).Add(task.DueDate.Sub(dateOf(task.DueDate))) // keep the time of day of the due date
+		if task.HasThresholdDate() {
+			next.ThresholdDate = next.DueDate.Add(task.ThresholdDate.Sub(task.DueDate))
+		}
+	case task.HasThresholdDate():
+		next.ThresholdDate = rec.Next(completedDate)
+	default:
+		next.DueDate = rec.Next(completedDate)
+	}
+	return next, nil
+}
+
+// Complete completes the task with the given id, see *Task.Complete().
+//
+// If the task recurs, the next occurrence is added to the TaskList and returned, see *Task.NextOccurrence().
+// Returns nil if the task does not recur or was already completed.
+// Returns ErrTaskNotFound if there is no such task, ErrUnparsedTask if it is unparsed, and ErrInvalidRecurrence if its 'rec:' tag can not be parsed.
+func (tasklist *TaskList) Complete(id int) (*Task, error) {
+	return tasklist.CompleteWith(id, nil)
+}
+
+// CompleteWith works like Complete(), using the given Clock for the completed date.
+func (tasklist *TaskList) CompleteWith(id int, clock Clock) (*Task, error) {
+	task, err := tasklist.GetTask(id)
+	if err != nil {
+		return nil, err
+	}
+	if task.Unparsed {
+		return nil, ErrUnparsedTask
+	}
+	if task.Completed {
+		return nil, nil
+	}
+	if !task.HasRecurrence() {
+		task.CompleteWith(clock)
+		return nil, nil
+	}
+	if _, err := task.Recurrence(); err != nil {
+		return nil, err
+	}
+
+	task.CompleteWith(clock)
+	next, err := task.NextOccurrence(task.CompletedDate)
+	if err != nil {
+		return nil, err
+	}
+
+	tasklist.AddTask(next) // might move the tasks of the TaskList, task must not be used anymore
+	return tasklist.GetTask(next.Id)
+}
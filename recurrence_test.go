@@ -0,0 +1,137 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	for text, expected := range map[string]Recurrence{
+		"1d":   {Interval: 1, Unit: RECURRENCE_DAY},
+		"2w":   {Interval: 2, Unit: RECURRENCE_WEEK},
+		"+1m":  {Strict: true, Interval: 1, Unit: RECURRENCE_MONTH},
+		"+10y": {Strict: true, Interval: 10, Unit: RECURRENCE_YEAR},
+	} {
+		rec, err := ParseRecurrence(text)
+		if err != nil {
+			t.Errorf("Expected [%s] to be parsed, but got error [%v]", text, err)
+			continue
+		}
+		testExpected = expected
+		testGot = rec
+		if testGot != testExpected {
+			t.Errorf("Expected [%s] to be parsed as [%v], but got [%v]", text, testExpected, testGot)
+		}
+		testExpected = text
+		testGot = rec.String()
+		if testGot != testExpected {
+			t.Errorf("Expected Recurrence to be [%s], but got [%s]", testExpected, testGot)
+		}
+	}
+
+	for _, text := range []string{"", "d", "+d", "0d", "-1d", "1x", "1.5w", "++1d", "+-1d"} {
+		if rec, err := ParseRecurrence(text); !errors.Is(err, ErrInvalidRecurrence) {
+			t.Errorf("Expected [%s] to be an invalid recurrence, but got [%v] and error [%v]", text, rec, err)
+		}
+	}
+}
+
+func TestTaskNextOccurrence(t *testing.T) {
+	completed := time.Date(2014, 1, 10, 17, 30, 0, 0, time.Local)
+
+	for text, expected := range map[string]string{
+		"(A) 2014-01-01 Water the plants @Home rec:1w due:2014-01-08":  "(A) 2014-01-10 Water the plants @Home rec:1w due:2014-01-17",
+		"(A) 2014-01-01 Water the plants @Home rec:+1w due:2014-01-08": "(A) 2014-01-10 Water the plants @Home rec:+1w due:2014-01-15",
+		"Pay rent rec:+1m t:2014-01-25 due:2014-01-31":                 "2014-01-10 Pay rent rec:+1m t:2014-02-25 due:2014-02-28",
+		"Pay rent rec:+1m due:2014-02-28":                              "2014-01-10 Pay rent rec:+1m due:2014-03-28",
+		"Celebrate leap day rec:+1y due:2012-02-29":                    "2014-01-10 Celebrate leap day rec:+1y due:2013-02-28",
+		"Pay rent rec:1m t:2014-01-25 due:2014-01-31":                  "2014-01-10 Pay rent rec:1m t:2014-02-04 due:2014-02-10",
+		"Renew passport rec:10y t:2014-01-01":                          "2014-01-10 Renew passport rec:10y t:2024-01-10",
+		"x 2014-01-09 2014-01-01 Take out trash rec:2d":                "2014-01-10 Take out trash rec:2d due:2014-01-12",
+		"Standup rec:1d t:2014-01-08 due:2014-01-09T09:30":             "2014-01-10 Standup rec:1d t:2014-01-10 due:2014-01-11T09:30",
+		"Standup rec:+1d due:2014-01-09T09:30":                         "2014-01-10 Standup rec:+1d due:2014-01-10T09:30",
+	} {
+		task, err := ParseTask(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		next, err := task.NextOccurrence(completed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testExpected = expected
+		testGot = next.String()
+		if testGot != testExpected {
+			t.Errorf("Expected next occurrence of [%s] to be [%s], but got [%s]", text, testExpected, testGot)
+		}
+	}
+
+	task, _ := ParseTask("Water the plants rec:often")
+	if next, err := task.NextOccurrence(completed); !errors.Is(err, ErrInvalidRecurrence) {
+		t.Errorf("Expected invalid recurrence error, but got [%v] and error [%v]", next, err)
+	}
+}
+
+func TestTaskListComplete(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("Call Mom\nWater the plants @Home rec:1d due:2014-01-08\nBroken rec:often\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := tasklist.Complete(1)
+	if err != nil || next != nil {
+		t.Errorf("Expected no next occurrence for Task[1], but got [%v] and error [%v]", next, err)
+	}
+	if !tasklist[0].Completed {
+		t.Errorf("Expected Task[1] to be completed, but it wasn't")
+	}
+
+	next, err = tasklist.CompleteWith(2, todotxttest.FixedClock(time.Date(2014, 1, 10, 9, 0, 0, 0, time.Local)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tasklist[1].Completed {
+		t.Errorf("Expected Task[2] to be completed, but it wasn't")
+	}
+	testExpected = 4
+	testGot = len(tasklist)
+	if testGot != testExpected {
+		t.Fatalf("Expected TaskList to contain %d tasks, but got %d", testExpected, testGot)
+	}
+	testExpected = 4
+	testGot = next.Id
+	if testGot != testExpected {
+		t.Errorf("Expected next occurrence to have Id [%d], but got [%d]", testExpected, testGot)
+	}
+	if next != &tasklist[3] {
+		t.Errorf("Expected next occurrence to point into the TaskList, but it didn't")
+	}
+	testExpected = "2014-01-11"
+	testGot = next.DueDate.Format(DateLayout)
+	if testGot != testExpected {
+		t.Errorf("Expected next occurrence to be due [%s], but got [%s]", testExpected, testGot)
+	}
+	if next.Completed {
+		t.Errorf("Expected next occurrence not to be completed, but it was")
+	}
+
+	if next, err := tasklist.Complete(2); err != nil || next != nil {
+		t.Errorf("Expected completed Task[2] not to recur again, but got [%v] and error [%v]", next, err)
+	}
+
+	if next, err := tasklist.Complete(3); !errors.Is(err, ErrInvalidRecurrence) || tasklist[2].Completed {
+		t.Errorf("Expected Task[3] to fail with invalid recurrence and stay open, but got [%v] and error [%v]", next, err)
+	}
+
+	if _, err := tasklist.Complete(99); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected ErrTaskNotFound, but got [%v]", err)
+	}
+}
@@ -5,7 +5,6 @@
 package todotxt
 
 import (
-	"errors"
 	"sort"
 	"time"
 )
@@ -20,10 +19,15 @@ const (
 	SORT_COMPLETED_DATE_DESC
 	SORT_DUE_DATE_ASC
 	SORT_DUE_DATE_DESC
+	SORT_THRESHOLD_DATE_ASC
+	SORT_THRESHOLD_DATE_DESC
+	SORT_DEPENDENCIES // Tasks after the tasks they depend on, see *TaskList.DependencyGraph()
 )
 
 // Sort allows a TaskList to be sorted by certain predefined fields.
 // See constants SORT_* for fields and sort order.
+//
+// SORT_DEPENDENCIES fails with DependencyErrors if there are dependency cycles, the TaskList is left unchanged then.
 func (tasklist *TaskList) Sort(sortFlag int) error {
 	switch sortFlag {
 	case SORT_PRIORITY_ASC, SORT_PRIORITY_DESC:
@@ -34,8 +38,12 @@ func (tasklist *TaskList) Sort(sortFlag int) error {
 		tasklist.sortByCompletedDate(sortFlag)
 	case SORT_DUE_DATE_ASC, SORT_DUE_DATE_DESC:
 		tasklist.sortByDueDate(sortFlag)
+	case SORT_THRESHOLD_DATE_ASC, SORT_THRESHOLD_DATE_DESC:
+		tasklist.sortByThresholdDate(sortFlag)
+	case SORT_DEPENDENCIES:
+		return tasklist.sortByDependencies()
 	default:
-		return errors.New("unrecognized sort option")
+		return ErrUnrecognizedSortOption
 	}
 	return nil
 }
@@ -113,7 +121,15 @@ func (tasklist *TaskList) sortByCompletedDate(order int) *TaskList {
 
 func (tasklist *TaskList) sortByDueDate(order int) *TaskList {
 	tasklist.sortBy(func(t1, t2 *Task) bool {
-		return sortByDate(order == SORT_DUE_DATE_ASC, t1.HasDueDate(), t2.HasDueDate(), t1.DueDate, t2.DueDate)
+		// Due dates without a time of day are sorted after the ones with a time on the same day
+		return sortByDate(order == SORT_DUE_DATE_ASC, t1.HasDueDate(), t2.HasDueDate(), t1.deadline(), t2.deadline())
+	})
+	return tasklist
+}
+
+func (tasklist *TaskList) sortByThresholdDate(order int) *TaskList {
+	tasklist.sortBy(func(t1, t2 *Task) bool {
+		return sortByDate(order == SORT_THRESHOLD_DATE_ASC, t1.HasThresholdDate(), t2.HasThresholdDate(), t1.ThresholdDate, t2.ThresholdDate)
 	})
 	return tasklist
 }
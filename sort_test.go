@@ -5,6 +5,7 @@
 package todotxt
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -324,6 +325,85 @@ func TestTaskSortByDueDate(t *testing.T) {
 	}
 }
 
+func TestTaskSortByThresholdDate(t *testing.T) {
+	testTasklist.LoadFromFilename(testInputSort)
+	taskId := 21
+
+	testTasklist = testTasklist[taskId : taskId+4]
+
+	if err := testTasklist.Sort(SORT_THRESHOLD_DATE_ASC); err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "Pick up milk"
+	testGot = testTasklist[0].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[1] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "x 2014-01-02 File taxes t:2014-01-01"
+	testGot = testTasklist[1].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[2] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "(A) Call Mom t:2014-01-15 due:2014-01-20"
+	testGot = testTasklist[2].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[3] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "Water the plants t:2014-03-01"
+	testGot = testTasklist[3].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[4] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if err := testTasklist.Sort(SORT_THRESHOLD_DATE_DESC); err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "Water the plants t:2014-03-01"
+	testGot = testTasklist[0].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[1] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "(A) Call Mom t:2014-01-15 due:2014-01-20"
+	testGot = testTasklist[1].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[2] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "x 2014-01-02 File taxes t:2014-01-01"
+	testGot = testTasklist[2].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[3] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "Pick up milk"
+	testGot = testTasklist[3].Task()
+	if testGot != testExpected {
+		t.Errorf("Expected Task[4] after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskSortByDueTime(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("Report due:2014-01-05\nCall due:2014-01-05T09:00\nMeeting due:2014-01-04T23:00\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tasklist.Sort(SORT_DUE_DATE_ASC); err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Meeting|Call|Report"
+	testGot = todos(&tasklist)
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList after Sort() to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
 func TestTaskSortError(t *testing.T) {
 	testTasklist.LoadFromFilename(testInputSort)
 
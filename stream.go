@@ -0,0 +1,152 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// Decoder reads tasks one by one from an io.Reader.
+//
+// Lines are read without any length limit. Blank lines and comments are skipped.
+//
+// For example:
+//
+//	decoder := NewDecoder(os.Stdin)
+//	for decoder.Next() {
+//		fmt.Println(decoder.Task().Todo)
+//	}
+//	if err := decoder.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+type Decoder struct {
+	reader *bufio.Reader
+	parser *Parser
+	task   *Task
+	err    error
+	line   int
+	taskId int
+}
+
+// NewDecoder creates a new Decoder reading from r, using the default options of NewParser().
+func NewDecoder(r io.Reader) *Decoder {
+	return NewParser().NewDecoder(r)
+}
+
+// NewDecoder creates a new Decoder reading from r, using the options of the Parser.
+func (parser *Parser) NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{
+		reader: bufio.NewReader(r),
+		parser: parser,
+		taskId: 1,
+	}
+}
+
+// Next reads the next task, which is then available through Task().
+// Returns false when there are no more tasks or an error occurred, which is then available through Err().
+//
+// If Err() returns a *ParseError, calling Next() again continues with the following line.
+func (dec *Decoder) Next() bool {
+	if dec.err != nil {
+		if _, ok := dec.err.(*ParseError); !ok {
+			return false
+		}
+		dec.err = nil
+	}
+	dec.task = nil
+
+	for {
+		line, _, err := readLine(dec.reader)
+		if err != nil {
+			if err != io.EOF {
+				dec.err = err
+			}
+			return false
+		}
+		dec.line++
+
+		// Ignore blank or comment lines
+		text := strings.Trim(line, "\t\n\r ")
+		if text == "" || dec.parser.isComment(text) {
+			continue
+		}
+
+		task, err := dec.parser.parseLine(line, dec.line)
+		if err != nil {
+			dec.err = err
+			return false
+		}
+		task.Id = dec.taskId
+		dec.taskId++
+		dec.task = task
+		return true
+	}
+}
+
+// Task returns the task read by the last call to Next().
+func (dec *Decoder) Task() *Task {
+	return dec.task
+}
+
+// Err returns the error that stopped the last call to Next(), or nil if there was none.
+func (dec *Decoder) Err() error {
+	return dec.err
+}
+
+// Line returns the number of lines read so far.
+func (dec *Decoder) Line() int {
+	return dec.line
+}
+
+// Encoder writes tasks one by one to an io.Writer.
+type Encoder struct {
+	w      io.Writer
+	writer *Writer
+}
+
+// NewEncoder creates a new Encoder writing to w, using the default options of NewWriter().
+func NewEncoder(w io.Writer) *Encoder {
+	return NewWriter().NewEncoder(w)
+}
+
+// NewEncoder creates a new Encoder writing to w, using the options of the Writer.
+func (writer *Writer) NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, writer: writer}
+}
+
+// Encode writes a single task in todo.txt format, followed by a line ending.
+func (enc *Encoder) Encode(task Task) error {
+	_, err := io.WriteString(enc.w, enc.writer.Format(task)+enc.writer.lineEnding())
+	return err
+}
+
+// EncodeList writes all tasks of a TaskList in todo.txt format.
+func (enc *Encoder) EncodeList(tasklist TaskList) error {
+	for _, task := range tasklist {
+		if err := enc.Encode(task); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLine reads a single line, without any length limit.
+// Returns the line text and its line ending separately, the line ending is empty for a last line without one.
+// Returns io.EOF only if there is nothing left to read.
+func readLine(reader *bufio.Reader) (string, string, error) {
+	text, err := reader.ReadString('\n')
+	if err == io.EOF && text != "" {
+		return text, "", nil
+	} else if err != nil {
+		return "", "", err
+	}
+
+	if strings.HasSuffix(text, "\r\n") {
+		return strings.TrimSuffix(text, "\r\n"), "\r\n", nil
+	}
+	return strings.TrimSuffix(text, "\n"), "\n", nil
+}
@@ -0,0 +1,133 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecoder(t *testing.T) {
+	input := "(A) Call Mom @Phone\r\n\n# comment\nx 2014-01-03 Pick up milk\nLast task without line ending +Home"
+	decoder := NewDecoder(strings.NewReader(input))
+
+	var todos []string
+	var ids []int
+	for decoder.Next() {
+		todos = append(todos, decoder.Task().Todo)
+		ids = append(ids, decoder.Task().Id)
+	}
+	if err := decoder.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "Call Mom|Pick up milk|Last task without line ending"
+	testGot = strings.Join(todos, "|")
+	if testGot != testExpected {
+		t.Errorf("Expected decoded tasks to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = 3
+	testGot = ids[2]
+	if testGot != testExpected {
+		t.Errorf("Expected last task to have Id [%d], but got [%d]", testExpected, testGot)
+	}
+	testExpected = 5
+	testGot = decoder.Line()
+	if testGot != testExpected {
+		t.Errorf("Expected decoder to have read [%d] lines, but got [%d]", testExpected, testGot)
+	}
+	if decoder.Next() {
+		t.Errorf("Expected no more tasks, but got [%v]", decoder.Task())
+	}
+}
+
+func TestDecoderParseError(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader("Call Mom\n2013-13-01 Invalid date\nPick up milk\n"))
+
+	if !decoder.Next() {
+		t.Fatalf("Expected first task, but got error [%v]", decoder.Err())
+	}
+	if decoder.Next() {
+		t.Fatalf("Expected second line to fail, but got [%v]", decoder.Task())
+	}
+	var parseError *ParseError
+	if !errors.As(decoder.Err(), &parseError) {
+		t.Fatalf("Expected error to be *ParseError, but got [%T]", decoder.Err())
+	}
+	testExpected = 2
+	testGot = parseError.Line
+	if testGot != testExpected {
+		t.Errorf("Expected ParseError on line [%d], but got [%d]", testExpected, testGot)
+	}
+
+	// continue after a *ParseError
+	if !decoder.Next() {
+		t.Fatalf("Expected third task, but got error [%v]", decoder.Err())
+	}
+	testExpected = "Pick up milk"
+	testGot = decoder.Task().Todo
+	if testGot != testExpected {
+		t.Errorf("Expected task to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = 2
+	testGot = decoder.Task().Id
+	if testGot != testExpected {
+		t.Errorf("Expected task to have Id [%d], but got [%d]", testExpected, testGot)
+	}
+}
+
+func TestDecoderLongLine(t *testing.T) {
+	long := strings.Repeat("word ", 30000) // ~150 KiB, more than bufio.Scanner can handle
+	decoder := NewDecoder(strings.NewReader("Short task\n" + long + "@Long\n"))
+
+	var tasks []*Task
+	for decoder.Next() {
+		tasks = append(tasks, decoder.Task())
+	}
+	if err := decoder.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = 2
+	testGot = len(tasks)
+	if testGot != testExpected {
+		t.Fatalf("Expected [%d] tasks, but got [%d]", testExpected, testGot)
+	}
+	testExpected = "Long"
+	testGot = tasks[1].Contexts[0]
+	if testGot != testExpected {
+		t.Errorf("Expected context of long task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("(B) Pick up milk @Store due:2014-01-12\nx Call Mom +Family @Phone\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).EncodeList(tasklist); err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "(B) Pick up milk @Store due:2014-01-12\nx Call Mom @Phone +Family\n"
+	testGot = buf.String()
+	if testGot != testExpected {
+		t.Errorf("Expected encoded TaskList to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	buf.Reset()
+	writer := &Writer{DateLayout: DateLayout, LineEnding: "\r\n"}
+	if err := writer.NewEncoder(&buf).Encode(tasklist[1]); err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "x Call Mom +Family @Phone\r\n"
+	testGot = buf.String()
+	if testGot != testExpected {
+		t.Errorf("Expected encoded Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
@@ -0,0 +1,295 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TagType is the type of the value of an additional tag, used for the typed tag accessors and for validation.
+type TagType int
+
+// Types of tag values.
+const (
+	TAG_STRING     TagType = iota // Any value.
+	TAG_INT                       // Integer: 'pages:12'
+	TAG_FLOAT                     // Floating point number: 'cost:12.50'
+	TAG_BOOL                      // Boolean, '1' or '0', 'true' or 'false': 'star:1'
+	TAG_DATE                      // Date in DateLayout: 'review:2014-01-12'
+	TAG_DURATION                  // Duration, in time.ParseDuration() format or in days: 'est:1h30m', 'est:2d'
+	TAG_LIST                      // Comma separated list: 'dep:abc,def'
+	TAG_RECURRENCE                // Recurrence, see ParseRecurrence(): 'rec:+1w'
+)
+
+// String returns the name of the tag type.
+func (tagType TagType) String() string {
+	switch tagType {
+	case TAG_INT:
+		return "int"
+	case TAG_FLOAT:
+		return "float"
+	case TAG_BOOL:
+		return "bool"
+	case TAG_DATE:
+		return "date"
+	case TAG_DURATION:
+		return "duration"
+	case TAG_LIST:
+		return "list"
+	case TAG_RECURRENCE:
+		return "recurrence"
+	}
+	return "string"
+}
+
+// tagTypes is the registry of RegisterTagType().
+var tagTypes = struct {
+	sync.RWMutex
+	types map[string]TagType
+}{types: make(map[string]TagType)}
+
+// RegisterTagType declares the type of the values of all tags with the given key.
+// ParseTask() returns a *ParseError for tags whose value does not match their registered type.
+//
+// The keys 'due', 't' and 'h' are read into their own Task fields and are not validated by this.
+// A Parser can use its own types instead of the registry, see Parser.TagTypes.
+func RegisterTagType(key string, tagType TagType) {
+	tagTypes.Lock()
+	defer tagTypes.Unlock()
+	tagTypes.types[key] = tagType
+}
+
+// UnregisterTagType removes the type of the given key from the registry, see RegisterTagType().
+func UnregisterTagType(key string) {
+	tagTypes.Lock()
+	defer tagTypes.Unlock()
+	delete(tagTypes.types, key)
+}
+
+// RegisteredTagType returns the registered type of the given key, see RegisterTagType().
+// Returns false if no type was registered for the key.
+func RegisteredTagType(key string) (TagType, bool) {
+	tagTypes.RLock()
+	defer tagTypes.RUnlock()
+	tagType, found := tagTypes.types[key]
+	return tagType, found
+}
+
+// tagType returns the type of the given key, taken from Parser.TagTypes or the registry.
+func (parser *Parser) tagType(key string) (TagType, bool) {
+	if parser.TagTypes != nil {
+		tagType, found := parser.TagTypes[key]
+		return tagType, found
+	}
+	return RegisteredTagType(key)
+}
+
+// validateTag returns an error if the value can not be read as the registered type of the key.
+func (parser *Parser) validateTag(key, value string) error {
+	tagType, found := parser.tagType(key)
+	if !found {
+		return nil
+	}
+	var err error
+	switch tagType {
+	case TAG_INT:
+		_, err = strconv.Atoi(value)
+	case TAG_FLOAT:
+		_, err = strconv.ParseFloat(value, 64)
+	case TAG_BOOL:
+		_, err = strconv.ParseBool(value)
+	case TAG_DATE:
+		_, err = parser.parseDate(value)
+	case TAG_DURATION:
+		_, err = parseDuration(value)
+	case TAG_RECURRENCE:
+		_, err = ParseRecurrence(value)
+	}
+	return err
+}
+
+// Tag returns the value of the tag with the given key.
+//
+// Returns ErrTagNotFound if the task has no such tag.
+func (task *Task) Tag(key string) (string, error) {
+	value, found := task.AdditionalTags[key]
+	if !found {
+		return "", ErrTagNotFound
+	}
+	return value, nil
+}
+
+// TagInt returns the value of the tag with the given key as an integer.
+//
+// Returns ErrTagNotFound if the task has no such tag, or a *strconv.NumError if the value is not an integer.
+func (task *Task) TagInt(key string) (int, error) {
+	value, err := task.Tag(key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(value)
+}
+
+// TagFloat returns the value of the tag with the given key as a floating point number.
+//
+// Returns ErrTagNotFound if the task has no such tag, or a *strconv.NumError if the value is not a number.
+func (task *Task) TagFloat(key string) (float64, error) {
+	value, err := task.Tag(key)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// TagBool returns the value of the tag with the given key as a boolean, accepting the values of strconv.ParseBool().
+//
+// Returns ErrTagNotFound if the task has no such tag, or a *strconv.NumError if the value is not a boolean.
+func (task *Task) TagBool(key string) (bool, error) {
+	value, err := task.Tag(key)
+	if err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(value)
+}
+
+// TagDate returns the value of the tag with the given key as a date in one of the DateLayouts of the Parser the task was read with.
+//
+// Returns ErrTagNotFound if the task has no such tag, or a *time.ParseError if the value is not a date.
+func (task *Task) TagDate(key string) (time.Time, error) {
+	value, err := task.Tag(key)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return task.parser().parseDate(value)
+}
+
+// TagDuration returns the value of the tag with the given key as a duration.
+// Durations are written as accepted by time.ParseDuration(), or as a number of days: '1h30m', '2d'
+//
+// Returns ErrTagNotFound if the task has no such tag, or an error if the value is not a duration.
+func (task *Task) TagDuration(key string) (time.Duration, error) {
+	value, err := task.Tag(key)
+	if err != nil {
+		return 0, err
+	}
+	return parseDuration(value)
+}
+
+// TagList returns the comma separated values of the tag with the given key. Empty values are left out.
+//
+// Returns ErrTagNotFound if the task has no such tag.
+func (task *Task) TagList(key string) ([]string, error) {
+	value, err := task.Tag(key)
+	if err != nil {
+		return nil, err
+	}
+	var list []string
+	for _, element := range strings.Split(value, ",") {
+		if element != "" {
+			list = append(list, element)
+		}
+	}
+	return list, nil
+}
+
+// SetTagInt sets the tag with the given key to an integer value.
+//
+// Returns ErrInvalidTagKey if the key can not be used for an additional tag, see setTag().
+func (task *Task) SetTagInt(key string, value int) error {
+	return task.setTag(key, strconv.Itoa(value))
+}
+
+// SetTagFloat sets the tag with the given key to a floating point value, written with as few digits as necessary.
+//
+// Returns ErrInvalidTagKey if the key can not be used for an additional tag, see setTag().
+func (task *Task) SetTagFloat(key string, value float64) error {
+	return task.setTag(key, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+// SetTagBool sets the tag with the given key to '1' or '0'.
+//
+// Returns ErrInvalidTagKey if the key can not be used for an additional tag, see setTag().
+func (task *Task) SetTagBool(key string, value bool) error {
+	if value {
+		return task.setTag(key, "1")
+	}
+	return task.setTag(key, "0")
+}
+
+// SetTagDate sets the tag with the given key to a date, formatted with the first date layout of the Parser the task was read with.
+//
+// Returns ErrInvalidTagKey if the key can not be used for an additional tag, see setTag().
+func (task *Task) SetTagDate(key string, value time.Time) error {
+	return task.setTag(key, value.Format(task.parser().writer().DateLayout))
+}
+
+// SetTagDuration sets the tag with the given key to a duration, written without zero units: '1h30m', '2h', '45m'
+//
+// Returns ErrInvalidTagKey if the key can not be used for an additional tag, see setTag().
+func (task *Task) SetTagDuration(key string, value time.Duration) error {
+	return task.setTag(key, formatDuration(value))
+}
+
+// SetTagList sets the tag with the given key to a comma separated list of values.
+//
+// Returns ErrInvalidTagKey if the key can not be used for an additional tag, see setTag(),
+// and ErrInvalidTagValue if the list is empty or a value is empty or contains a comma or whitespace.
+func (task *Task) SetTagList(key string, values []string) error {
+	for _, value := range values {
+		if value == "" || strings.ContainsRune(value, ',') {
+			return ErrInvalidTagValue
+		}
+	}
+	return task.setTag(key, strings.Join(values, ","))
+}
+
+// setTag sets the tag with the given key to value.
+//
+// Returns ErrInvalidTagKey if the key is not accepted by DefaultTagKey(), or is 'due', 't' or 'h',
+// which have their own Task fields, and ErrInvalidTagValue if the value is empty or contains whitespace.
+func (task *Task) setTag(key, value string) error {
+	if task.Unparsed {
+		return ErrUnparsedTask
+	}
+	if !DefaultTagKey(key) || key == "due" || key == "t" || key == "h" {
+		return ErrInvalidTagKey
+	}
+	if value == "" || strings.ContainsAny(value, " \t\n\r\f") {
+		return ErrInvalidTagValue
+	}
+	if task.AdditionalTags == nil {
+		task.AdditionalTags = make(map[string]string)
+	}
+	if task.AdditionalTags[key] != value {
+		task.AdditionalTags[key] = value
+		task.changed()
+	}
+	return nil
+}
+
+// parseDuration parses a duration in time.ParseDuration() format, or a number of days: '2d'
+func parseDuration(text string) (time.Duration, error) {
+	if strings.HasSuffix(text, "d") {
+		if days, err := strconv.Atoi(text[:len(text)-1]); err == nil {
+			return time.Duration(days) * 24 * time.Hour, nil
+		}
+	}
+	return time.ParseDuration(text)
+}
+
+// formatDuration formats a duration like time.Duration.String(), but without trailing zero units: '1h30m' instead of '1h30m0s'
+func formatDuration(d time.Duration) string {
+	text := d.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = text[:len(text)-2]
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = text[:len(text)-2]
+	}
+	return text
+}
@@ -0,0 +1,168 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTaskTypedTags(t *testing.T) {
+	task, err := ParseTask("Write report pages:12 cost:12.50 star:1 review:2014-01-12 est:1h30m days:2d dep:abc,,def")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pages, err := task.TagInt("pages"); err != nil || pages != 12 {
+		t.Errorf("Expected pages to be [12], but got [%v] and error [%v]", pages, err)
+	}
+	if cost, err := task.TagFloat("cost"); err != nil || cost != 12.5 {
+		t.Errorf("Expected cost to be [12.5], but got [%v] and error [%v]", cost, err)
+	}
+	if star, err := task.TagBool("star"); err != nil || !star {
+		t.Errorf("Expected star to be [true], but got [%v] and error [%v]", star, err)
+	}
+	if review, err := task.TagDate("review"); err != nil || review.Format(DateLayout) != "2014-01-12" {
+		t.Errorf("Expected review to be [2014-01-12], but got [%v] and error [%v]", review, err)
+	}
+	if est, err := task.TagDuration("est"); err != nil || est != 90*time.Minute {
+		t.Errorf("Expected est to be [1h30m], but got [%v] and error [%v]", est, err)
+	}
+	if days, err := task.TagDuration("days"); err != nil || days != 48*time.Hour {
+		t.Errorf("Expected days to be [48h], but got [%v] and error [%v]", days, err)
+	}
+	if dep, err := task.TagList("dep"); err != nil || fmt.Sprint(dep) != "[abc def]" {
+		t.Errorf("Expected dep to be [abc def], but got %v and error [%v]", dep, err)
+	}
+
+	var numError *strconv.NumError
+	if _, err := task.TagInt("cost"); !errors.As(err, &numError) {
+		t.Errorf("Expected *strconv.NumError for cost as int, but got [%v]", err)
+	}
+	if _, err := task.TagInt("missing"); !errors.Is(err, ErrTagNotFound) {
+		t.Errorf("Expected ErrTagNotFound, but got [%v]", err)
+	}
+}
+
+func TestTaskSetTypedTags(t *testing.T) {
+	task := Task{Todo: "Write report"}
+	for _, err := range []error{
+		task.SetTagInt("pages", 12),
+		task.SetTagFloat("cost", 12.5),
+		task.SetTagBool("star", true),
+		task.SetTagDate("review", time.Date(2014, 1, 12, 0, 0, 0, 0, time.UTC)),
+		task.SetTagDuration("est", 90*time.Minute),
+		task.SetTagDuration("spent", 2*time.Hour),
+		task.SetTagList("dep", []string{"abc", "def"}),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testExpected = "Write report cost:12.5 dep:abc,def est:1h30m pages:12 review:2014-01-12 spent:2h star:1"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	for _, key := range []string{"due", "t", "h", "no key", "123", ""} {
+		if err := task.SetTagInt(key, 1); !errors.Is(err, ErrInvalidTagKey) {
+			t.Errorf("Expected ErrInvalidTagKey for key [%s], but got [%v]", key, err)
+		}
+	}
+	for _, list := range [][]string{nil, {"a,b"}, {"a", ""}, {"a b"}} {
+		if err := task.SetTagList("dep", list); !errors.Is(err, ErrInvalidTagValue) {
+			t.Errorf("Expected ErrInvalidTagValue for list %q, but got [%v]", list, err)
+		}
+	}
+}
+
+func TestRegisterTagType(t *testing.T) {
+	RegisterTagType("est", TAG_DURATION)
+	RegisterTagType("rec", TAG_RECURRENCE)
+	defer UnregisterTagType("est")
+	defer UnregisterTagType("rec")
+
+	if tagType, found := RegisteredTagType("est"); !found || tagType != TAG_DURATION {
+		t.Errorf("Expected est to be registered as [duration], but got [%v, %v]", tagType, found)
+	}
+	if _, err := ParseTask("Write report est:2h rec:+1w"); err != nil {
+		t.Error(err)
+	}
+
+	_, err := ParseTask("Write report est:soon")
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("Expected *ParseError, but got [%v]", err)
+	}
+	testExpected = `column 18: invalid est tag: time: invalid duration "soon"`
+	testGot = err.Error()
+	if testGot != testExpected {
+		t.Errorf("Expected error to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = "est"
+	testGot = parseError.Field
+	if testGot != testExpected {
+		t.Errorf("Expected error field to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if _, err := ParseTask("Water the plants rec:often"); !errors.Is(err, ErrInvalidRecurrence) {
+		t.Errorf("Expected ErrInvalidRecurrence, but got [%v]", err)
+	}
+
+	// A Parser with its own types does not use the registry
+	parser := NewParser()
+	parser.TagTypes = map[string]TagType{"pages": TAG_INT}
+	if _, err := parser.ParseTask("Write report est:soon rec:often"); err != nil {
+		t.Error(err)
+	}
+	if _, err := parser.ParseTask("Write report pages:many"); err == nil {
+		t.Errorf("Expected ParseTask to fail because of invalid pages tag, but it didn't!")
+	}
+
+	UnregisterTagType("est")
+	if _, err := ParseTask("Write report est:soon"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTaskListTypedTagErrors(t *testing.T) {
+	RegisterTagType("pages", TAG_INT)
+	defer UnregisterTagType("pages")
+
+	_, err := LoadFromReader(strings.NewReader("Write report pages:12\nRead book pages:many\n"))
+	testExpected = `line 2, column 17: invalid pages tag: strconv.Atoi: parsing "many": invalid syntax`
+	if err == nil || err.Error() != testExpected {
+		t.Errorf("Expected error to be [%s], but got [%v]", testExpected, err)
+	}
+}
+
+func TestTaskTagDateParser(t *testing.T) {
+	parser := NewParser()
+	parser.DateLayouts = []string{"02.01.2006"}
+	parser.TagTypes = map[string]TagType{"review": TAG_DATE}
+	task, err := parser.ParseTask("Write report review:12.01.2014")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if review, err := task.TagDate("review"); err != nil || !review.Equal(time.Date(2014, 1, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected review to be [12.01.2014], but got [%v] and error [%v]", review, err)
+	}
+
+	if err := task.SetTagDate("review", time.Date(2014, 1, 19, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "Write report review:19.01.2014"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
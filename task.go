@@ -5,10 +5,8 @@
 package todotxt
 
 import (
-	"fmt"
-	"regexp"
+	"net/url"
 	"sort"
-	"strings"
 	"time"
 )
 
@@ -16,16 +14,24 @@ var (
 	// DateLayout is used for formatting time.Time into todo.txt date format and vice-versa.
 	DateLayout = "2006-01-02"
 
-	priorityRx = regexp.MustCompile(`^(x|x \d{4}-\d{2}-\d{2}|)\s*\(([A-Z])\)\s+`) // Match priority: '(A) ...' or 'x (A) ...' or 'x 2012-12-12 (A) ...'
-	// Match created date: '(A) 2012-12-12 ...' or 'x 2012-12-12 (A) 2012-12-12 ...' or 'x (A) 2012-12-12 ...'or 'x 2012-12-12 2012-12-12 ...' or '2012-12-12 ...'
-	createdDateRx   = regexp.MustCompile(`^(\([A-Z]\)|x \d{4}-\d{2}-\d{2} \([A-Z]\)|x \([A-Z]\)|x \d{4}-\d{2}-\d{2}|)\s*(\d{4}-\d{2}-\d{2})\s+`)
-	completedRx     = regexp.MustCompile(`^x\s+`)                       // Match completed: 'x ...'
-	completedDateRx = regexp.MustCompile(`^x\s*(\d{4}-\d{2}-\d{2})\s+`) // Match completed date: 'x 2012-12-12 ...'
-	addonTagRx      = regexp.MustCompile(`(^|\s+)([\w-]+):(\S+)`)       // Match additional tags date: '... due:2012-12-12 ...'
-	contextRx       = regexp.MustCompile(`(^|\s+)@(\S+)`)               // Match contexts: '@Context ...' or '... @Context ...'
-	projectRx       = regexp.MustCompile(`(^|\s+)\+(\S+)`)              // Match projects: '+Project...' or '... +Project ...')
+	// PreserveOrder is used to switch to lossless serialization of tasks.
+	// If this is set to 'true', then unmodified tasks are written back exactly as found in Task.Original,
+	// and modified tasks keep their tokens in their original positions.
+	PreserveOrder = false
+
+	// Location is the default of Parser.Location, the time zone used to decide which calendar day it is now, see *Task.IsOverdue().
+	// Dates of tasks are wall clock dates, they do not have a time zone on their own.
+	// Queries also use it to resolve relative dates, see CompileQuery().
+	Location = time.Local
+
+	// CompletedPriority is the default of Parser.Priority, which decides what happens to the priority of a task when it is completed.
+	// See PriorityPolicy and NewParser().
+	CompletedPriority = PRIORITY_KEEP
 )
 
+// DueTimeLayout is used for formatting the time of day of a due date, appended to the date: 'due:2014-01-12T14:30'
+const DueTimeLayout = "15:04"
+
 // Task represents a todo.txt task entry.
 type Task struct {
 	Id             int    // Internal task id.
@@ -36,9 +42,17 @@ type Task struct {
 	Contexts       []string
 	AdditionalTags map[string]string // Addon tags will be available here.
 	CreatedDate    time.Time
-	DueDate        time.Time
+	DueDate        time.Time // Also contains a time of day if set by 'due:2014-01-12T14:30' or 'due:2014-01-12 at:14:30'.
+	ThresholdDate  time.Time // Task should not be worked on before this date, read from the 't:' tag.
 	CompletedDate  time.Time
 	Completed      bool
+	Hidden         bool        // Task only declares projects and contexts and is not real work, read from the 'h:1' tag.
+	Links          []string    // Links found in the todo text, they are also kept as part of Task.Todo.
+	Unparsed       bool        // Task could not be parsed, it only contains its Original text and can not be changed. See Lenient.
+	Warning        *ParseError // Reason why the task could not be parsed.
+	Dirty          bool        // Task was changed by one of its mutation methods, like *Task.SetPriority(), since it was read.
+
+	parsedBy *Parser // Parser the task was read with, see *Task.parser().
 }
 
 // String returns a complete task string in todo.txt format.
@@ -48,160 +62,144 @@ type Task struct {
 // Contexts, Projects, Tags
 //
 // For example:
-//  "(A) 2013-07-23 Call Dad @Home @Phone +Family due:2013-07-31 customTag1:Important!"
+//  "(A) 2013-07-23 Call Dad @Home @Phone +Family customTag1:Important! t:2013-07-25 due:2013-07-31"
+//
+// If PreserveOrder is set to 'true', the task is instead written in the token order of Task.Original.
+// See *Task.PreservedString() for further information.
+// Unparsed tasks are always written as Task.Original.
+//
+// Words of Task.Todo that would be read back as something else are escaped with a backslash,
+// so that ParseTask() returns the same task again: 'x', '(A)' or a date as first word, '@Home', '+Family', 'key:value', '\path'
+// Only a task without any todo text, contexts, projects or tags is read back differently,
+// as completion, priority and created date are only read as such if more text follows.
+// Contexts, projects, tag keys and tag values must not contain whitespace.
+//
+// The package level defaults are used for formatting, see NewWriter() and *Writer.Format() for other options.
 func (task Task) String() string {
-	var text string
-
-	if task.Completed {
-		text += "x "
-		if task.HasCompletedDate() {
-			text += fmt.Sprintf("%s ", task.CompletedDate.Format(DateLayout))
-		}
-	}
-
-	if task.HasPriority() {
-		text += fmt.Sprintf("(%s) ", task.Priority)
-	}
+	return NewWriter().Format(task)
+}
 
-	if task.HasCreatedDate() {
-		text += fmt.Sprintf("%s ", task.CreatedDate.Format(DateLayout))
-	}
+// PreservedString returns a complete task string in todo.txt format, keeping the token order of Task.Original.
+//
+// If the task has not been modified since it was parsed, Task.Original is returned unchanged.
+// Otherwise only the tokens that changed are rewritten in their original positions,
+// removed tokens are dropped and new contexts, projects and additional tags are appended at the end.
+//
+// Task.Original is read again with the Parser the task was read with, whose first date layout is also used for writing dates.
+// Tasks without Task.Original are formatted the same way as *Task.String() does.
+func (task Task) PreservedString() string {
+	return task.parser().writer().Format(task)
+}
 
-	text += task.Todo
+// equals returns true if both tasks have the same content, not taking their Id and Original text into account.
+func (task *Task) equals(other *Task) bool {
+	return task.Completed == other.Completed &&
+		task.HasCompletedDate() == other.HasCompletedDate() &&
+		(!task.HasCompletedDate() || task.CompletedDate.Equal(other.CompletedDate)) &&
+		task.Priority == other.Priority &&
+		task.CreatedDate.Equal(other.CreatedDate) &&
+		task.Todo == other.Todo &&
+		task.DueDate.Equal(other.DueDate) &&
+		task.ThresholdDate.Equal(other.ThresholdDate) &&
+		task.Hidden == other.Hidden &&
+		compareStrings(sortedStrings(task.Contexts), sortedStrings(other.Contexts)) &&
+		compareStrings(sortedStrings(task.Projects), sortedStrings(other.Projects)) &&
+		compareTags(task.AdditionalTags, other.AdditionalTags)
+}
 
-	if len(task.Contexts) > 0 {
-		sort.Strings(task.Contexts)
-		for _, context := range task.Contexts {
-			text += fmt.Sprintf(" @%s", context)
-		}
+// parser returns the Parser the task was read with, or NewParser() for tasks that were not read by a Parser.
+func (task *Task) parser() *Parser {
+	if task.parsedBy == nil {
+		return NewParser()
 	}
+	return task.parsedBy
+}
 
-	if len(task.Projects) > 0 {
-		sort.Strings(task.Projects)
-		for _, project := range task.Projects {
-			text += fmt.Sprintf(" +%s", project)
+// copy returns a copy of the task, not sharing any slices or maps with it.
+func (task *Task) copy() *Task {
+	copied := *task
+	copied.Projects = append([]string(nil), task.Projects...)
+	copied.Contexts = append([]string(nil), task.Contexts...)
+	copied.Links = append([]string(nil), task.Links...)
+	if task.AdditionalTags != nil {
+		copied.AdditionalTags = make(map[string]string, len(task.AdditionalTags))
+		for key, value := range task.AdditionalTags {
+			copied.AdditionalTags[key] = value
 		}
 	}
+	return &copied
+}
 
-	if len(task.AdditionalTags) > 0 {
-		// Sort map alphabetically by keys
-		keys := make([]string, 0, len(task.AdditionalTags))
-		for key := range task.AdditionalTags {
-			keys = append(keys, key)
-		}
-		sort.Strings(keys)
-		for _, key := range keys {
-			text += fmt.Sprintf(" %s:%s", key, task.AdditionalTags[key])
+func containsString(slice []string, s string) bool {
+	for _, element := range slice {
+		if element == s {
+			return true
 		}
 	}
-
-	if task.HasDueDate() {
-		text += fmt.Sprintf(" due:%s", task.DueDate.Format(DateLayout))
-	}
-
-	return text
+	return false
 }
 
-// NewTask creates a new empty Task with default values. (CreatedDate is set to Now())
-func NewTask() Task {
-	task := Task{}
-	task.CreatedDate = time.Now()
-	return task
+// sortedStrings returns a sorted copy of slice.
+func sortedStrings(slice []string) []string {
+	sorted := make([]string, len(slice))
+	copy(sorted, slice)
+	sort.Strings(sorted)
+	return sorted
 }
 
-// ParseTask parses the input text string into a Task struct.
-func ParseTask(text string) (*Task, error) {
-	var err error
-
-	task := Task{}
-	task.Original = strings.Trim(text, "\t\n\r ")
-	task.Todo = task.Original
-
-	// Check for completed
-	if completedRx.MatchString(task.Original) {
-		task.Completed = true
-		// Check for completed date
-		if completedDateRx.MatchString(task.Original) {
-			if date, err := time.Parse(DateLayout, completedDateRx.FindStringSubmatch(task.Original)[1]); err == nil {
-				task.CompletedDate = date
-			} else {
-				return nil, err
-			}
-		}
-
-		// Remove from Todo text
-		task.Todo = completedDateRx.ReplaceAllString(task.Todo, "") // Strip CompletedDate first, otherwise it wouldn't match anymore (^x date...)
-		task.Todo = completedRx.ReplaceAllString(task.Todo, "")     // Strip 'x '
-	}
-
-	// Check for priority
-	if priorityRx.MatchString(task.Original) {
-		task.Priority = priorityRx.FindStringSubmatch(task.Original)[2]
-		task.Todo = priorityRx.ReplaceAllString(task.Todo, "") // Remove from Todo text
+// sortedKeys returns the alphabetically sorted keys of a map.
+func sortedKeys(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
+	return keys
+}
 
-	// Check for created date
-	if createdDateRx.MatchString(task.Original) {
-		if date, err := time.Parse(DateLayout, createdDateRx.FindStringSubmatch(task.Original)[2]); err == nil {
-			task.CreatedDate = date
-			task.Todo = createdDateRx.ReplaceAllString(task.Todo, "") // Remove from Todo text
-		} else {
-			return nil, err
-		}
+func compareStrings(slice1, slice2 []string) bool {
+	if len(slice1) != len(slice2) {
+		return false
 	}
-
-	// function for collecting projects/contexts as slices from text
-	getSlice := func(rx *regexp.Regexp) []string {
-		matches := rx.FindAllStringSubmatch(task.Original, -1)
-		slice := make([]string, 0, len(matches))
-		seen := make(map[string]bool, len(matches))
-		for _, match := range matches {
-			word := strings.Trim(match[2], "\t\n\r ")
-			if _, found := seen[word]; !found {
-				slice = append(slice, word)
-				seen[word] = true
-			}
+	for i := range slice1 {
+		if slice1[i] != slice2[i] {
+			return false
 		}
-		sort.Strings(slice)
-		return slice
-	}
-
-	// Check for contexts
-	if contextRx.MatchString(task.Original) {
-		task.Contexts = getSlice(contextRx)
-		task.Todo = contextRx.ReplaceAllString(task.Todo, "") // Remove from Todo text
 	}
+	return true
+}
 
-	// Check for projects
-	if projectRx.MatchString(task.Original) {
-		task.Projects = getSlice(projectRx)
-		task.Todo = projectRx.ReplaceAllString(task.Todo, "") // Remove from Todo text
+func compareTags(tags1, tags2 map[string]string) bool {
+	if len(tags1) != len(tags2) {
+		return false
 	}
-
-	// Check for additional tags
-	if addonTagRx.MatchString(task.Original) {
-		matches := addonTagRx.FindAllStringSubmatch(task.Original, -1)
-		tags := make(map[string]string, len(matches))
-		for _, match := range matches {
-			key, value := match[2], match[3]
-			if key == "due" { // due date is a known addon tag, it has its own struct field
-				if date, err := time.Parse(DateLayout, value); err == nil {
-					task.DueDate = date
-				} else {
-					return nil, err
-				}
-			} else if key != "" && value != "" {
-				tags[key] = value
-			}
+	for key, value := range tags1 {
+		if value2, found := tags2[key]; !found || value != value2 {
+			return false
 		}
-		task.AdditionalTags = tags
-		task.Todo = addonTagRx.ReplaceAllString(task.Todo, "") // Remove from Todo text
 	}
+	return true
+}
 
-	// Trim any remaining whitespaces from Todo text
-	task.Todo = strings.Trim(task.Todo, "\t\n\r\f ")
+// NewTask creates a new empty Task with default values. (CreatedDate is set to DefaultClock.Now())
+func NewTask() Task {
+	return NewTaskWith(DefaultClock)
+}
 
-	return &task, err
+// NewTaskWith creates a new empty Task with default values, using the given Clock for CreatedDate.
+func NewTaskWith(clock Clock) Task {
+	task := Task{}
+	task.CreatedDate = clockNow(clock)
+	return task
+}
+
+// ParseTask parses the input text string into a Task struct.
+//
+// Returns a *ParseError if any of the dates or typed tags in the text can not be parsed.
+//
+// The package level defaults are used for parsing, see NewParser() and *Parser.ParseTask() for other options.
+func ParseTask(text string) (*Task, error) {
+	return NewParser().ParseTask(text)
 }
 
 // Task returns a complete task string in todo.txt format.
@@ -215,6 +213,23 @@ func (task *Task) HasPriority() bool {
 	return task.Priority != ""
 }
 
+// HasLinks returns true if the todo text of the task contains any links.
+func (task *Task) HasLinks() bool {
+	return len(task.Links) > 0
+}
+
+// URLs returns the links found in the todo text of the task as parsed URLs.
+// Links that can not be parsed are left out.
+func (task *Task) URLs() []*url.URL {
+	urls := make([]*url.URL, 0, len(task.Links))
+	for _, link := range task.Links {
+		if u, err := url.Parse(link); err == nil {
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
 // HasCreatedDate returns true if the task has a created date.
 func (task *Task) HasCreatedDate() bool {
 	return !task.CreatedDate.IsZero()
@@ -225,48 +240,160 @@ func (task *Task) HasDueDate() bool {
 	return !task.DueDate.IsZero()
 }
 
+// HasThresholdDate returns true if the task has a threshold date.
+func (task *Task) HasThresholdDate() bool {
+	return !task.ThresholdDate.IsZero()
+}
+
 // HasCompletedDate returns true if the task has a completed date.
 func (task *Task) HasCompletedDate() bool {
 	return !task.CompletedDate.IsZero() && task.Completed
 }
 
 // Complete sets Task.Completed to 'true' if the task was not already completed.
-// Also sets Task.CompletedDate to time.Now()
+// Also sets Task.CompletedDate to the current time of the task's Clock, see Parser.Clock, and handles the priority as set by
+// the Parser.Priority option of the Parser the task was read with, see PriorityPolicy.
+//
+// Recurring tasks are not repeated by this, see *TaskList.Complete() for that.
+// Unparsed tasks are not changed, see Task.Unparsed.
 func (task *Task) Complete() {
-	if !task.Completed {
+	task.CompleteWith(nil)
+}
+
+// CompleteWith works like Complete(), using the given Clock for Task.CompletedDate.
+func (task *Task) CompleteWith(clock Clock) {
+	if !task.Completed && !task.Unparsed {
 		task.Completed = true
-		task.CompletedDate = time.Now()
+		task.CompletedDate = clockNow(task.clock(clock))
+		task.parser().Priority.applyTo(task)
+		task.changed()
 	}
 }
 
 // Reopen sets Task.Completed to 'false' if the task was completed.
-// Also resets Task.CompletedDate.
+// Also resets Task.CompletedDate, and restores the priority from a 'pri:' tag, see PRIORITY_TAG.
+// Unparsed tasks are not changed, see Task.Unparsed.
 func (task *Task) Reopen() {
-	if task.Completed {
+	if task.Completed && !task.Unparsed {
 		task.Completed = false
 		task.CompletedDate = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC) // time.IsZero() value
+		task.restorePriority()
+		task.changed()
+	}
+}
+
+// HasDueTime returns true if the due date of the task also has a time of day.
+// A time of exactly midnight can not be told apart from a due date without time.
+func (task *Task) HasDueTime() bool {
+	if !task.HasDueDate() {
+		return false
 	}
+	hour, minute, second := task.DueDate.Clock()
+	return hour != 0 || minute != 0 || second != 0 || task.DueDate.Nanosecond() != 0
 }
 
 // IsOverdue returns true if due date is in the past.
 //
+// Due dates without a time of day are not overdue before the next calendar day has started,
+// due dates with a time of day are overdue as soon as that time has passed.
+// The current calendar day and time are taken in the Parser.Location of the Parser the task was read with.
+//
 // This function does not take the Completed flag into consideration.
 // You should check Task.Completed first if needed.
 func (task *Task) IsOverdue() bool {
+	return task.IsOverdueWith(nil)
+}
+
+// IsOverdueWith works like IsOverdue(), taking the current time from the given Clock.
+func (task *Task) IsOverdueWith(clock Clock) bool {
 	if task.HasDueDate() {
-		return task.DueDate.Before(time.Now())
+		return task.deadline().Before(task.now(clock))
 	}
 	return false
 }
 
+// IsFutureThreshold returns true if threshold date is after today, meaning the task should not be worked on yet.
+// The current calendar day is taken in the Parser.Location of the Parser the task was read with.
+func (task *Task) IsFutureThreshold() bool {
+	return task.IsFutureThresholdWith(nil)
+}
+
+// IsFutureThresholdWith works like IsFutureThreshold(), taking the current day from the given Clock.
+func (task *Task) IsFutureThresholdWith(clock Clock) bool {
+	if task.HasThresholdDate() {
+		return dateOf(wallClock(task.ThresholdDate)).After(dateOf(task.now(clock)))
+	}
+	return false
+}
+
+// IsActive returns true if the task is not completed and its threshold date, if any, has been reached.
+func (task *Task) IsActive() bool {
+	return task.IsActiveWith(nil)
+}
+
+// IsActiveWith works like IsActive(), taking the current day from the given Clock.
+func (task *Task) IsActiveWith(clock Clock) bool {
+	return !task.Completed && !task.IsFutureThresholdWith(clock)
+}
+
 // Due returns the duration passed since due date, or until due date from now.
 // Check with IsOverdue() if the task is overdue or not.
+// Due dates without a time of day count until the end of that day, see IsOverdue().
 //
 // Just as with IsOverdue(), this function does also not take the Completed flag into consideration.
 // You should check Task.Completed first if needed.
 func (task *Task) Due() time.Duration {
-	if task.IsOverdue() {
-		return time.Now().Sub(task.DueDate)
+	return task.DueWith(nil)
+}
+
+// DueWith works like Due(), taking the current time from the given Clock.
+func (task *Task) DueWith(clock Clock) time.Duration {
+	current, deadline := task.now(clock), task.deadline()
+	if task.HasDueDate() && deadline.Before(current) {
+		return current.Sub(deadline)
+	}
+	return deadline.Sub(current)
+}
+
+// deadline returns the wall clock time at which the task becomes overdue.
+func (task *Task) deadline() time.Time {
+	due := wallClock(task.DueDate)
+	if task.HasDueTime() {
+		return due
 	}
-	return task.DueDate.Sub(time.Now())
+	return due.AddDate(0, 0, 1)
+}
+
+// clock returns the given Clock, or the Parser.Clock of the Parser the task was read with if it is nil.
+func (task *Task) clock(clock Clock) Clock {
+	if clock == nil {
+		return task.parser().Clock
+	}
+	return clock
+}
+
+// now returns the current wall clock time of clock in the Parser.Location of the task, see *Task.clock().
+func (task *Task) now(clock Clock) time.Time {
+	return now(task.clock(clock), task.parser().Location)
+}
+
+// now returns the current wall clock time of clock in the given location, or in Location if nil.
+func now(clock Clock, location *time.Location) time.Time {
+	if location == nil {
+		location = Location
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return wallClock(clockNow(clock).In(location))
+}
+
+// wallClock returns the date and time of day of t as UTC, so that it can be compared with parsed dates.
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// dateOf returns the date of t at midnight, in the location of t.
+func dateOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
 }
@@ -5,8 +5,11 @@
 package todotxt
 
 import (
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
 )
 
 var (
@@ -168,6 +171,71 @@ func TestParseTask(t *testing.T) {
 	}
 }
 
+func TestParseTaskError(t *testing.T) {
+	task, err := ParseTask("(A) 2014-01-01 Call Mom due:2014-13-01")
+	if task != nil || err == nil {
+		t.Fatalf("Expected ParseTask to fail because of invalid due date, but got Task back: [%v]", task)
+	}
+
+	parseError, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("Expected error to be a *ParseError, but got [%T]", err)
+	}
+	testExpected = "column 29: invalid due date: parsing time \"2014-13-01\": month out of range"
+	testGot = parseError.Error()
+	if testGot != testExpected {
+		t.Errorf("Expected error to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = "(A) 2014-01-01 Call Mom due:2014-13-01"
+	testGot = parseError.Text
+	if testGot != testExpected {
+		t.Errorf("Expected error for text [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestParseTaskLinks(t *testing.T) {
+	task, err := ParseTask("Read https://example.com/x, meet at 10:30 and call +1 555 1234 +Work (see http://example.org)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "Read https://example.com/x, meet at 10:30 and call +1 555 1234 (see http://example.org)"
+	testGot = task.Todo
+	if testGot != testExpected {
+		t.Errorf("Expected Task to have todo text [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = []string{"Work"}
+	testGot = task.Projects
+	if !compareSlices(testGot.([]string), testExpected.([]string)) {
+		t.Errorf("Expected Task to have projects '%v', but got '%v'", testExpected, testGot)
+	}
+
+	testExpected = 0
+	testGot = len(task.AdditionalTags)
+	if testGot != testExpected {
+		t.Errorf("Expected Task to have %d additional tags, but got [%v]", testExpected, task.AdditionalTags)
+	}
+
+	testExpected = []string{"https://example.com/x", "http://example.org"}
+	testGot = task.Links
+	if !task.HasLinks() || !compareSlices(testGot.([]string), testExpected.([]string)) {
+		t.Errorf("Expected Task to have links '%v', but got '%v'", testExpected, testGot)
+	}
+
+	testExpected = "example.com"
+	testGot = task.URLs()[0].Host
+	if testGot != testExpected {
+		t.Errorf("Expected first URL to have host [%s], but got [%s]", testExpected, testGot)
+	}
+
+	testExpected = "Read https://example.com/x, meet at 10:30 and call +1 555 1234 (see http://example.org) +Work"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
 func TestTaskId(t *testing.T) {
 	testTasklist.LoadFromFilename(testInputTask)
 
@@ -231,6 +299,99 @@ func TestTaskString(t *testing.T) {
 	taskId++
 }
 
+func TestTaskPreservedString(t *testing.T) {
+	task, err := ParseTask("x (C) 2014-01-01 @Go due:2014-01-12 Create golang  library documentation +go-todotxt   ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "x (C) 2014-01-01 @Go due:2014-01-12 Create golang  library documentation +go-todotxt"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected unmodified Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.Priority = "A"
+	task.DueDate = task.DueDate.AddDate(0, 0, 1)
+	task.Contexts = append(task.Contexts, "Home")
+	testExpected = "x (A) 2014-01-01 @Go due:2014-01-13 Create golang  library documentation +go-todotxt @Home"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected modified Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.Reopen()
+	task.Todo = "Write documentation"
+	task.Projects = nil
+	task.AdditionalTags = map[string]string{"Level": "5"}
+	testExpected = "(A) 2014-01-01 @Go due:2014-01-13 Write documentation @Home Level:5"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected modified Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.Original = ""
+	testExpected = task.Task()
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task without original text to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskStringPreserveOrder(t *testing.T) {
+	PreserveOrder = true
+	defer func() { PreserveOrder = false }()
+
+	task, err := ParseTask("(B) 2013-12-01 private:false Outline chapter 5 +Novel @Computer Level:5 due:2014-02-17")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "(B) 2013-12-01 private:false Outline chapter 5 +Novel @Computer Level:5 due:2014-02-17"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.AdditionalTags["private"] = "true"
+	testExpected = "(B) 2013-12-01 private:true Outline chapter 5 +Novel @Computer Level:5 due:2014-02-17"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskStringEscaping(t *testing.T) {
+	for todo, expected := range map[string]string{
+		"x marks the spot":           `\x marks the spot`,
+		"(B) is the priority":        `\(B) is the priority`,
+		"2014-01-01 was a Wednesday": `\2014-01-01 was a Wednesday`,
+		"Mail to @Home and +Family":  `Mail to \@Home and \+Family`,
+		"Set key:value  twice":       `Set \key:value  twice`,
+		`Copy \server\share`:         `Copy \\server\share`,
+		"Read https://example.com":   "Read https://example.com",
+		"Call (B) x 2014-01-01":      "Call (B) x 2014-01-01",
+	} {
+		task := Task{Priority: "A", Todo: todo, Contexts: []string{"Home"}}
+		testExpected = "(A) " + expected + " @Home"
+		testGot = task.String()
+		if testGot != testExpected {
+			t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+			continue
+		}
+
+		parsed, err := ParseTask(task.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		testExpected = todo
+		testGot = parsed.Todo
+		if testGot != testExpected {
+			t.Errorf("Expected Task to be read back with Todo [%s], but got [%s]", testExpected, testGot)
+		}
+	}
+}
+
 func TestTaskPriority(t *testing.T) {
 	testTasklist.LoadFromFilename(testInputTask)
 	taskId := 6
@@ -401,6 +562,103 @@ func TestTaskDueDate(t *testing.T) {
 	taskId++
 }
 
+func TestTaskThresholdDate(t *testing.T) {
+	task, err := ParseTask("(A) Water the plants t:2014-03-01 @Home due:2014-03-05")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = time.Date(2014, 3, 1, 0, 0, 0, 0, time.UTC)
+	testGot = task.ThresholdDate
+	if testGot != testExpected {
+		t.Errorf("Expected Task to have threshold date '%v', but got '%v'", testExpected, testGot)
+	}
+	if _, found := task.AdditionalTags["t"]; found {
+		t.Errorf("Expected threshold date not to be an additional tag, but got [%v]", task.AdditionalTags)
+	}
+
+	testExpected = "(A) Water the plants @Home t:2014-03-01 due:2014-03-05"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.ThresholdDate = time.Date(2014, 3, 2, 0, 0, 0, 0, time.UTC)
+	testExpected = "(A) Water the plants t:2014-03-02 @Home due:2014-03-05"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if _, err := ParseTask("Water the plants t:2014-13-01"); err == nil {
+		t.Errorf("Expected ParseTask to fail because of invalid threshold date, but it didn't!")
+	} else if err.Error() != `column 20: invalid threshold date: parsing time "2014-13-01": month out of range` {
+		t.Error(err)
+	}
+}
+
+func TestTaskHidden(t *testing.T) {
+	task, err := ParseTask("@Garden +Landscaping h:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !task.Hidden {
+		t.Errorf("Expected Task to be hidden, but it wasn't")
+	}
+	if _, found := task.AdditionalTags["h"]; found {
+		t.Errorf("Expected 'h:1' not to be an additional tag, but got [%v]", task.AdditionalTags)
+	}
+
+	testExpected = "@Garden +Landscaping h:1"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.Hidden = false
+	testExpected = "@Garden +Landscaping"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task, err = ParseTask("Plant roses h:0 t:2014-03-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.Hidden {
+		t.Errorf("Expected Task with 'h:0' not to be hidden, but it was")
+	}
+	task.Hidden = true
+	testExpected = "Plant roses h:0 t:2014-03-01 h:1"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskIsActive(t *testing.T) {
+	task := Task{Todo: "Water the plants"}
+	if task.IsFutureThreshold() || !task.IsActive() {
+		t.Errorf("Expected Task without threshold date to be active, but it wasn't")
+	}
+
+	task.ThresholdDate = time.Now().AddDate(0, 0, 2)
+	if !task.IsFutureThreshold() || task.IsActive() {
+		t.Errorf("Expected Task with future threshold date not to be active, but it was")
+	}
+
+	task.ThresholdDate = time.Now().AddDate(0, 0, -2)
+	if task.IsFutureThreshold() || !task.IsActive() {
+		t.Errorf("Expected Task with past threshold date to be active, but it wasn't")
+	}
+
+	task.Complete()
+	if task.IsActive() {
+		t.Errorf("Expected completed Task not to be active, but it was")
+	}
+}
+
 func TestTaskAddonTags(t *testing.T) {
 	testTasklist.LoadFromFilename(testInputTask)
 	taskId := 25
@@ -569,6 +827,166 @@ func TestTaskIsOverdue(t *testing.T) {
 	taskId++
 }
 
+func TestTaskDueTime(t *testing.T) {
+	for text, expected := range map[string]string{
+		"Meet Bob due:2014-01-12T14:30":          "Meet Bob due:2014-01-12T14:30",
+		"Meet Bob due:2014-01-12 at:14:30 @Work": "Meet Bob @Work due:2014-01-12T14:30",
+		"Meet Bob at:14:30 @Work":                "Meet Bob @Work at:14:30",
+		"Meet Bob due:2014-01-12T00:00":          "Meet Bob due:2014-01-12",
+		"Meet Bob due:2014-01-12 at:noon @Work":  "Meet Bob @Work at:noon due:2014-01-12",
+		"Meet Bob due:2014-01-12T09:05 at:14:30": "Meet Bob at:14:30 due:2014-01-12T09:05",
+	} {
+		task, err := ParseTask(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		testExpected = expected
+		testGot = task.String()
+		if testGot != testExpected {
+			t.Errorf("Expected Task [%s] to be [%s], but got [%s]", text, testExpected, testGot)
+		}
+	}
+
+	task, err := ParseTask("Meet Bob due:2014-01-12 at:14:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = time.Date(2014, 1, 12, 14, 30, 0, 0, time.UTC)
+	testGot = task.DueDate
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be due at [%v], but got [%v]", testExpected, testGot)
+	}
+	if !task.HasDueTime() {
+		t.Errorf("Expected Task to have a due time, but it didn't")
+	}
+	task.Todo = "Meet Alice"
+	testExpected = "Meet Alice due:2014-01-12 at:14:30"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to keep its 'at:' tag as [%s], but got [%s]", testExpected, testGot)
+	}
+	task.SetDueDate(time.Date(2014, 1, 13, 9, 0, 0, 0, time.UTC))
+	testExpected = "Meet Alice due:2014-01-13 at:09:00"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to keep its 'at:' tag as [%s], but got [%s]", testExpected, testGot)
+	}
+	task.SetDueDate(time.Date(2014, 1, 13, 0, 0, 0, 0, time.UTC))
+	testExpected = "Meet Alice due:2014-01-13"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task without due time to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	if _, err := ParseTask("Meet Bob due:2014-01-12T25:00"); err == nil {
+		t.Errorf("Expected ParseTask to fail because of invalid due time, but it didn't!")
+	} else if err.Error() != `column 14: invalid due date: parsing time "25:00": hour out of range` {
+		t.Error(err)
+	}
+}
+
+func TestTaskIsOverdueCalendarDay(t *testing.T) {
+	defer func(location *time.Location) { Location = location }(Location)
+	Location = time.UTC
+	clock := todotxttest.FixedClock(time.Date(2024, 3, 11, 11, 0, 0, 0, time.UTC))
+	date := func(days int) time.Time {
+		return time.Date(2024, 3, 11+days, 0, 0, 0, 0, time.UTC)
+	}
+
+	task := Task{Todo: "Due today", DueDate: date(0)}
+	if task.IsOverdueWith(clock) {
+		t.Errorf("Expected Task due today not to be overdue, but it was")
+	}
+	if task.DueWith(clock) != 13*time.Hour {
+		t.Errorf("Expected Task due today to be due until the end of the day, but got [%v]", task.DueWith(clock))
+	}
+
+	task.DueDate = date(-1)
+	if !task.IsOverdueWith(clock) {
+		t.Errorf("Expected Task due yesterday to be overdue, but it wasn't")
+	}
+
+	task.DueDate = clock.Now().Add(-time.Minute)
+	if !task.IsOverdueWith(clock) {
+		t.Errorf("Expected Task due a minute ago to be overdue, but it wasn't")
+	}
+	if task.DueWith(clock) != time.Minute {
+		t.Errorf("Expected Task to be overdue for a minute, but got [%v]", task.DueWith(clock))
+	}
+	task.DueDate = clock.Now().Add(time.Hour)
+	if task.IsOverdueWith(clock) {
+		t.Errorf("Expected Task due in an hour not to be overdue, but it was")
+	}
+
+	// The calendar day depends on Location, 2024-03-11 11:00 UTC is already 2024-03-12 in UTC+14
+	task.DueDate = date(0)
+	Location = time.FixedZone("UTC+14", 14*3600)
+	if !task.IsOverdueWith(clock) {
+		t.Errorf("Expected Task due yesterday in [%v] to be overdue, but it wasn't", Location)
+	}
+	Location = time.FixedZone("UTC-12", -12*3600)
+	if task.IsOverdueWith(clock) {
+		t.Errorf("Expected Task due today not to be overdue in [%v], but it was", Location)
+	}
+
+	// The Location of the Parser takes precedence
+	parser := NewParser()
+	parser.Location = time.FixedZone("UTC+14", 14*3600)
+	parsed, err := parser.ParseTask("Due today due:2024-03-11")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !parsed.IsOverdueWith(clock) {
+		t.Errorf("Expected Task due yesterday in [%v] to be overdue, but it wasn't", parser.Location)
+	}
+}
+
+func TestTaskClock(t *testing.T) {
+	defer func(clock Clock) { DefaultClock = clock }(DefaultClock)
+	defer func(location *time.Location) { Location = location }(Location)
+	Location = time.UTC
+	clock := todotxttest.NewFakeClock(time.Date(2024, 3, 7, 18, 0, 0, 0, time.UTC)) // Thursday
+	DefaultClock = clock
+
+	task := NewTask()
+	testExpected = "2024-03-07"
+	testGot = task.CreatedDate.Format(DateLayout)
+	if testGot != testExpected {
+		t.Errorf("Expected new Task to be created on [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.DueDate = time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)
+	task.ThresholdDate = time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)
+	if task.IsOverdue() || !task.IsFutureThreshold() || task.IsActive() {
+		t.Errorf("Expected Task due tomorrow to be neither overdue nor active, but got [%v, %v]", task.IsOverdue(), task.IsActive())
+	}
+
+	// What will be the state next Monday?
+	monday := todotxttest.FixedClock(time.Date(2024, 3, 11, 9, 0, 0, 0, time.UTC))
+	if !task.IsOverdueWith(monday) || task.IsFutureThresholdWith(monday) || !task.IsActiveWith(monday) {
+		t.Errorf("Expected Task to be overdue and active next Monday, but got [%v, %v]", task.IsOverdueWith(monday), task.IsActiveWith(monday))
+	}
+	if task.DueWith(monday) != 57*time.Hour {
+		t.Errorf("Expected Task to be overdue for 57 hours next Monday, but got [%v]", task.DueWith(monday))
+	}
+
+	clock.AdvanceDays(1)
+	task.Complete()
+	testExpected = "2024-03-08"
+	testGot = task.CompletedDate.Format(DateLayout)
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be completed on [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task = NewTaskWith(monday)
+	task.CompleteWith(monday)
+	testExpected = "2024-03-11 2024-03-11"
+	testGot = task.CreatedDate.Format(DateLayout) + " " + task.CompletedDate.Format(DateLayout)
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be created and completed on [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
 func TestTaskComplete(t *testing.T) {
 	testTasklist.LoadFromFilename(testInputTask)
 	taskId := 44
@@ -734,3 +1152,56 @@ func compareMaps(map1 map[string]string, map2 map[string]string) bool {
 
 	return compare(map1, map2) && compare(map2, map1)
 }
+
+func FuzzParseTaskRoundTrip(f *testing.F) {
+	f.Add("x 2014-01-03 (C) 2014-01-01 @Go due:2014-01-12 Create golang Level:5 +go-todotxt")
+	f.Add("(A) x 2014-01-01")
+	f.Add("@Home key:value")
+	f.Add(`\@Home \\ \x`)
+	f.Add("Read https://example.com/@x+y:z")
+	f.Fuzz(func(t *testing.T, text string) {
+		task, err := ParseTask(text)
+		if err != nil {
+			return
+		}
+		// Tasks consisting only of completion, priority and created date can not be written unambiguously
+		var suffix strings.Builder
+		NewWriter().suffix(&suffix, *task)
+		if suffix.Len() == 0 {
+			return
+		}
+		for _, output := range []string{task.String(), task.PreservedString()} {
+			again, err := ParseTask(output)
+			if err != nil {
+				t.Fatalf("Expected [%s] written as [%s] to be parsed again, but got [%v]", text, output, err)
+			}
+			if !again.equals(task) {
+				t.Errorf("Expected [%s] written as [%s] to be parsed as [%#v], but got [%#v]", text, output, *task, *again)
+			}
+		}
+	})
+}
+
+func FuzzTodoRoundTrip(f *testing.F) {
+	f.Add("x marks the spot", "B", true)
+	f.Add("2014-01-01 @Home +Family due:soon", "", false)
+	f.Add(`\\server\share (A)`, "A", true)
+	f.Fuzz(func(t *testing.T, todo string, priority string, completed bool) {
+		// Todo text is trimmed and can not contain line breaks
+		if todo != strings.Trim(todo, "\t\n\r\f ") || strings.ContainsAny(todo, "\n\r") {
+			return
+		}
+		if len(priority) != 1 || priority[0] < 'A' || priority[0] > 'Z' {
+			priority = ""
+		}
+		task := Task{Todo: todo, Priority: priority, Completed: completed, Contexts: []string{"Home"}}
+
+		again, err := ParseTask(task.String())
+		if err != nil {
+			t.Fatalf("Expected [%s] to be parsed again, but got [%v]", task.String(), err)
+		}
+		if !again.equals(&task) {
+			t.Errorf("Expected [%s] to be parsed as [%#v], but got [%#v]", task.String(), task, *again)
+		}
+	})
+}
@@ -0,0 +1,293 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// TimerLayout is the layout of the start time in the 'timer:' tag of a task whose timer is running.
+const TimerLayout = time.RFC3339
+
+// Estimate returns the value of the 'est:' tag of the task, the estimated time to complete it: 'est:90m', 'est:2h', 'est:1d'
+//
+// Returns ErrTagNotFound if the task has no such tag, or an error if it is not a duration, see *Task.TagDuration().
+func (task *Task) Estimate() (time.Duration, error) {
+	return task.TagDuration("est")
+}
+
+// Spent returns the value of the 'spent:' tag of the task, the time worked on it so far.
+// Time of a running timer is not included.
+//
+// Returns ErrTagNotFound if the task has no such tag, or an error if it is not a duration, see *Task.TagDuration().
+func (task *Task) Spent() (time.Duration, error) {
+	return task.TagDuration("spent")
+}
+
+// SetEstimate sets the 'est:' tag of the task.
+func (task *Task) SetEstimate(estimate time.Duration) error {
+	return task.SetTagDuration("est", estimate)
+}
+
+// SetSpent sets the 'spent:' tag of the task.
+func (task *Task) SetSpent(spent time.Duration) error {
+	return task.SetTagDuration("spent", spent)
+}
+
+// IsTimerRunning returns true if the task has a 'timer:' tag, see *TimeTracker.Start().
+func (task *Task) IsTimerRunning() bool {
+	_, found := task.AdditionalTags["timer"]
+	return found
+}
+
+// TimerStart returns the time at which the timer of the task was started.
+//
+// Returns ErrTimerNotRunning if the task has no 'timer:' tag, or a *time.ParseError if it can not be parsed.
+func (task *Task) TimerStart() (time.Time, error) {
+	value, found := task.AdditionalTags["timer"]
+	if !found {
+		return time.Time{}, ErrTimerNotRunning
+	}
+	return time.Parse(TimerLayout, value)
+}
+
+// TimeEntry is a single work session on a task, as written to the timelog file.
+//
+// Entries are written one per line: start and end time in TimerLayout, the duration, and the task text with its contexts and projects:
+//
+//	2014-01-12T13:30:00Z 2014-01-12T15:00:00Z 1h30m Write report @Office +Work
+type TimeEntry struct {
+	Start time.Time
+	End   time.Time
+	Task  string // Todo text, contexts and projects of the task, as written by *Task.String().
+}
+
+// Duration returns the length of the work session.
+func (entry TimeEntry) Duration() time.Duration {
+	return entry.End.Sub(entry.Start)
+}
+
+// String returns the entry as a line of the timelog file, without line ending.
+func (entry TimeEntry) String() string {
+	return strings.Join([]string{
+		entry.Start.UTC().Format(TimerLayout),
+		entry.End.UTC().Format(TimerLayout),
+		formatDuration(entry.Duration()),
+		entry.Task,
+	}, " ")
+}
+
+// ParseTimeEntry parses a line of the timelog file into a TimeEntry.
+// The duration written in the line is only informational, the entry's duration is always calculated from start and end.
+func ParseTimeEntry(text string) (TimeEntry, error) {
+	fields := strings.SplitN(strings.Trim(text, "\t\n\r "), " ", 4)
+	if len(fields) < 4 {
+		return TimeEntry{}, fmt.Errorf("invalid time entry: %q", text)
+	}
+	start, err := time.Parse(TimerLayout, fields[0])
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	end, err := time.Parse(TimerLayout, fields[1])
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	return TimeEntry{Start: start, End: end, Task: fields[3]}, nil
+}
+
+// TimeTracker starts and stops timers on tasks, logging each work session to a timelog file.
+//
+// The TimeTracker only modifies the given tasks, they still have to be written back to todo.txt afterwards.
+type TimeTracker struct {
+	Filename string // Timelog file, work sessions are appended to it.
+	Clock    Clock  // Clock used for start and end times. DefaultClock is used if nil.
+}
+
+// NewTimeTracker creates a new TimeTracker, logging to the file "timelog.txt" in the same directory as the given todo.txt file.
+func NewTimeTracker(todoFilename string) *TimeTracker {
+	return &TimeTracker{Filename: filepath.Join(filepath.Dir(todoFilename), "timelog.txt")}
+}
+
+// Start starts the timer of the task, by setting its 'timer:' tag to the current time.
+//
+// Returns ErrTimerRunning if the timer of the task is already running.
+func (tracker *TimeTracker) Start(task *Task) error {
+	if task.IsTimerRunning() {
+		return ErrTimerRunning
+	}
+	return task.setTag("timer", clockNow(tracker.Clock).UTC().Format(TimerLayout))
+}
+
+// Stop stops the timer of the task, adds the time since it was started to its 'spent:' tag and appends the work session to the timelog file.
+// Times are counted in whole seconds.
+//
+// Returns ErrTimerNotRunning if the timer of the task is not running. The task is not modified if the timelog file can not be written.
+func (tracker *TimeTracker) Stop(task *Task) (TimeEntry, error) {
+	start, err := task.TimerStart()
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	entry := TimeEntry{
+		Start: start,
+		End:   clockNow(tracker.Clock).UTC().Truncate(time.Second),
+		Task:  timeEntryTask(task),
+	}
+	if entry.End.Before(entry.Start) {
+		entry.End = entry.Start
+	}
+
+	file, err := os.OpenFile(tracker.Filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return TimeEntry{}, err
+	}
+	if _, err := io.WriteString(file, entry.String()+"\n"); err != nil {
+		file.Close()
+		return TimeEntry{}, err
+	}
+	if err := file.Close(); err != nil {
+		return TimeEntry{}, err
+	}
+
+	spent, _ := task.Spent() // an invalid 'spent:' tag starts again from zero
+	task.DeleteTag("timer")
+	return entry, task.SetSpent(spent + entry.Duration())
+}
+
+// Entries returns all work sessions of the timelog file, in the order they were logged.
+// Returns no entries if the file does not exist yet.
+func (tracker *TimeTracker) Entries() ([]TimeEntry, error) {
+	file, err := os.Open(tracker.Filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []TimeEntry
+	reader := bufio.NewReader(file)
+	for {
+		line, _, err := readLine(reader)
+		if err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, err := ParseTimeEntry(line)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// timeEntryTask returns the todo text, contexts and projects of the task, identifying it in the timelog file.
+func timeEntryTask(task *Task) string {
+	return (&Task{Todo: task.Todo, Contexts: task.Contexts, Projects: task.Projects}).String()
+}
+
+// TimeSummary holds the estimated and spent time of a group of tasks.
+type TimeSummary struct {
+	Name     string        // Name of the project or context, empty for the total.
+	Tasks    int           // Number of tasks in the group.
+	Estimate time.Duration // Sum of all 'est:' tags.
+	Spent    time.Duration // Sum of all 'spent:' tags.
+}
+
+// Remaining returns the estimated time minus the spent time, which is negative if more time was spent than estimated.
+func (summary TimeSummary) Remaining() time.Duration {
+	return summary.Estimate - summary.Spent
+}
+
+// add adds the estimated and spent time of the task to the summary.
+func (summary *TimeSummary) add(task *Task) {
+	summary.Tasks++
+	if estimate, err := task.Estimate(); err == nil {
+		summary.Estimate += estimate
+	}
+	if spent, err := task.Spent(); err == nil {
+		summary.Spent += spent
+	}
+}
+
+// TimeReport compares estimated and spent time per project and per context.
+type TimeReport struct {
+	Projects []TimeSummary // One summary per project, sorted by name.
+	Contexts []TimeSummary // One summary per context, sorted by name.
+	Total    TimeSummary   // Summary of all tasks.
+}
+
+// TimeReport sums up the 'est:' and 'spent:' tags of all tasks, per project and per context.
+// Tasks with several projects or contexts count for each of them. Tags that are not valid durations are ignored.
+// Hidden tasks are left out, see Task.Hidden.
+func (tasklist *TaskList) TimeReport() *TimeReport {
+	report := &TimeReport{}
+	projects := make(map[string]*TimeSummary)
+	contexts := make(map[string]*TimeSummary)
+	summary := func(summaries map[string]*TimeSummary, name string) *TimeSummary {
+		if summaries[name] == nil {
+			summaries[name] = &TimeSummary{Name: name}
+		}
+		return summaries[name]
+	}
+
+	for i := range *tasklist {
+		task := &(*tasklist)[i]
+		if task.Hidden {
+			continue
+		}
+		report.Total.add(task)
+		for _, project := range task.Projects {
+			summary(projects, project).add(task)
+		}
+		for _, context := range task.Contexts {
+			summary(contexts, context).add(task)
+		}
+	}
+
+	sorted := func(summaries map[string]*TimeSummary) []TimeSummary {
+		list := make([]TimeSummary, 0, len(summaries))
+		for _, summary := range summaries {
+			list = append(list, *summary)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+		return list
+	}
+	report.Projects = sorted(projects)
+	report.Contexts = sorted(contexts)
+	return report
+}
+
+// String returns the report as a table, with one row per project and context and a final total:
+//
+//	+Work     3 tasks  est 5h  spent 5h30m  remaining -30m
+func (report *TimeReport) String() string {
+	var text strings.Builder
+	w := tabwriter.NewWriter(&text, 0, 0, 2, ' ', 0)
+	row := func(name string, summary TimeSummary) {
+		fmt.Fprintf(w, "%s\t%d tasks\test %s\tspent %s\tremaining %s\n",
+			name, summary.Tasks, formatDuration(summary.Estimate), formatDuration(summary.Spent), formatDuration(summary.Remaining()))
+	}
+	for _, summary := range report.Projects {
+		row("+"+summary.Name, summary)
+	}
+	for _, summary := range report.Contexts {
+		row("@"+summary.Name, summary)
+	}
+	row("Total", report.Total)
+	w.Flush()
+	return text.String()
+}
@@ -0,0 +1,180 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
+)
+
+func TestTimeTracker(t *testing.T) {
+	dir := t.TempDir()
+	clock := todotxttest.NewFakeClock(time.Date(2014, 1, 12, 13, 30, 0, 0, time.UTC))
+	tracker := NewTimeTracker(filepath.Join(dir, "todo.txt"))
+	tracker.Clock = clock
+
+	testExpected = filepath.Join(dir, "timelog.txt")
+	testGot = tracker.Filename
+	if testGot != testExpected {
+		t.Errorf("Expected timelog file to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task, err := ParseTask("(A) Write report @Office +Work est:2h spent:30m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.Stop(task); !errors.Is(err, ErrTimerNotRunning) {
+		t.Errorf("Expected ErrTimerNotRunning, but got [%v]", err)
+	}
+
+	if err := tracker.Start(task); err != nil {
+		t.Fatal(err)
+	}
+	if err := tracker.Start(task); !errors.Is(err, ErrTimerRunning) {
+		t.Errorf("Expected ErrTimerRunning, but got [%v]", err)
+	}
+	testExpected = "(A) Write report @Office +Work est:2h spent:30m timer:2014-01-12T13:30:00Z"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	// The running timer survives writing and reading the task again
+	task, err = ParseTask(task.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(90 * time.Minute)
+	entry, err := tracker.Stop(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Duration() != 90*time.Minute {
+		t.Errorf("Expected work session of 1h30m, but got [%v]", entry.Duration())
+	}
+	testExpected = "(A) Write report @Office +Work est:2h spent:2h"
+	testGot = task.String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	tracker.Start(task)
+	clock.Advance(45*time.Minute + 20*time.Second)
+	if _, err := tracker.Stop(task); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(tracker.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "2014-01-12T13:30:00Z 2014-01-12T15:00:00Z 1h30m Write report @Office +Work\n" +
+		"2014-01-12T15:00:00Z 2014-01-12T15:45:20Z 45m20s Write report @Office +Work\n"
+	testGot = string(data)
+	if testGot != testExpected {
+		t.Errorf("Expected timelog to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	entries, err := tracker.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = 2
+	testGot = len(entries)
+	if testGot != testExpected {
+		t.Fatalf("Expected %d time entries, but got %d", testExpected, testGot)
+	}
+	if entries[1].Duration() != 45*time.Minute+20*time.Second || entries[1].Task != "Write report @Office +Work" {
+		t.Errorf("Expected second time entry to be [%s], but got [%s]", strings.Split(string(data), "\n")[1], entries[1])
+	}
+	if spent, err := task.Spent(); err != nil || spent != 2*time.Hour+45*time.Minute+20*time.Second {
+		t.Errorf("Expected 2h45m20s to be spent, but got [%v] and error [%v]", spent, err)
+	}
+
+	// A work session that took no time still stops the timer of a loaded task
+	task, err = ParseTask("Write report spent:1h timer:2014-01-12T15:45:20Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tracker.Stop(task); err != nil {
+		t.Fatal(err)
+	}
+	if !task.Dirty {
+		t.Errorf("Expected Task to be dirty after stopping its timer, but it wasn't")
+	}
+	testExpected = "Write report spent:1h"
+	testGot = task.PreservedString()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTimeTrackerEntriesMissingFile(t *testing.T) {
+	tracker := NewTimeTracker(filepath.Join(t.TempDir(), "todo.txt"))
+	if entries, err := tracker.Entries(); err != nil || len(entries) != 0 {
+		t.Errorf("Expected no time entries, but got %v and error [%v]", entries, err)
+	}
+}
+
+func TestTimeTrackerEntriesLongLine(t *testing.T) {
+	tracker := NewTimeTracker(filepath.Join(t.TempDir(), "todo.txt"))
+	todo := strings.Repeat("Write a very long report ", 4000)
+	data := "2014-01-12T13:30:00Z 2014-01-12T15:00:00Z 1h30m " + todo + "\r\n\n" +
+		"2014-01-12T15:00:00Z 2014-01-12T15:45:20Z 45m20s Call Mom"
+	if err := ioutil.WriteFile(tracker.Filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := tracker.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = 2
+	testGot = len(entries)
+	if testGot != testExpected {
+		t.Fatalf("Expected %d time entries, but got %d", testExpected, testGot)
+	}
+	if entries[0].Task != strings.TrimSpace(todo) {
+		t.Errorf("Expected first time entry to keep its long todo text, but got [%d] characters", len(entries[0].Task))
+	}
+	testExpected = "Call Mom"
+	testGot = entries[1].Task
+	if testGot != testExpected {
+		t.Errorf("Expected second time entry to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestTaskListTimeReport(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader(`Write report @Office +Work est:2h spent:1h
+Review slides @Office +Work +Talk est:90m spent:2h
+Call Mom @Phone est:15m
+Water the plants spent:soon
++Work @Office est:8h spent:1h h:1
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := tasklist.TimeReport()
+	testExpected = `+Talk    1 tasks  est 1h30m  spent 2h  remaining -30m
++Work    2 tasks  est 3h30m  spent 3h  remaining 30m
+@Office  2 tasks  est 3h30m  spent 3h  remaining 30m
+@Phone   1 tasks  est 15m    spent 0s  remaining 15m
+Total    4 tasks  est 3h45m  spent 3h  remaining 45m
+`
+	testGot = report.String()
+	if testGot != testExpected {
+		t.Errorf("Expected TimeReport to be [%s], but got [%s]", testExpected, testGot)
+	}
+	if report.Projects[1].Name != "Work" || report.Projects[1].Remaining() != 30*time.Minute {
+		t.Errorf("Expected +Work to have 30m remaining, but got [%v]", report.Projects[1])
+	}
+}
@@ -9,12 +9,9 @@
 package todotxt
 
 import (
-	"bufio"
-	"errors"
-	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
-	"strings"
+	"sort"
 )
 
 // TaskList represents a list of todo.txt task entries.
@@ -23,10 +20,23 @@ type TaskList []Task
 
 // IgnoreComments can be set to 'false', in order to revert to a more standard todo.txt behaviour.
 // The todo.txt format does not define comments.
+//
+// These package level variables are only used as defaults by NewParser() and NewWriter().
+// Use your own Parser and Writer to load and save tasks with different options at the same time.
 var (
 	// IgnoreComments is used to switch ignoring of comments (lines starting with "#").
 	// If this is set to 'false', then lines starting with "#" will be parsed as tasks.
 	IgnoreComments = true
+
+	// Lenient is used to switch to a recovering load mode.
+	// If this is set to 'true', then lines that can not be parsed do not abort loading a TaskList,
+	// instead they are kept as unparsed tasks. See Task.Unparsed and TaskList.Warnings().
+	Lenient = false
+
+	// GenerateUIDs is used to give every task added to a TaskList or Document a stable identity.
+	// If this is set to 'true', then *TaskList.AddTask() adds a 'uid:' tag to tasks that do not have one yet. See *Task.UID().
+	// It is the default of Document.GenerateUIDs, see NewDocument(). Use *TaskList.AddTaskWithUID() to decide per call instead.
+	GenerateUIDs = false
 )
 
 // NewTaskList creates a new empty TaskList.
@@ -36,15 +46,27 @@ func NewTaskList() TaskList {
 }
 
 // String returns a complete list of tasks in todo.txt format.
-func (tasklist TaskList) String() (text string) {
-	for _, task := range tasklist {
-		text += fmt.Sprintf("%s\n", task.String())
-	}
-	return text
+func (tasklist TaskList) String() string {
+	return NewWriter().FormatList(tasklist)
 }
 
 // AddTask appends a Task to the current TaskList and takes care to set the Task.Id correctly, modifying the Task by the given pointer!
+// If GenerateUIDs is set, a 'uid:' tag is added to the Task too.
+// A 'uid:' tag that is already used by another Task of the TaskList is replaced by a new one.
 func (tasklist *TaskList) AddTask(task *Task) {
+	tasklist.addTask(task, GenerateUIDs)
+}
+
+// AddTaskWithUID works like AddTask(), but always adds a 'uid:' tag to the Task if it has none yet, see *Task.UID().
+func (tasklist *TaskList) AddTaskWithUID(task *Task) {
+	tasklist.addTask(task, true)
+}
+
+// addTask appends a Task to the TaskList, adding a 'uid:' tag if generateUID is set.
+func (tasklist *TaskList) addTask(task *Task, generateUID bool) {
+	if generateUID || task.HasUID() {
+		task.ensureUID(tasklist.pointers())
+	}
 	task.Id = 0
 	for _, t := range *tasklist {
 		if t.Id > task.Id {
@@ -57,18 +79,18 @@ func (tasklist *TaskList) AddTask(task *Task) {
 }
 
 // GetTask returns a Task by given task 'id' from the TaskList. The returned Task pointer can be used to update the Task inside the TaskList.
-// Returns an error if Task could not be found.
+// Returns ErrTaskNotFound if Task could not be found.
 func (tasklist *TaskList) GetTask(id int) (*Task, error) {
 	for i := range *tasklist {
 		if ([]Task(*tasklist))[i].Id == id {
 			return &([]Task(*tasklist))[i], nil
 		}
 	}
-	return nil, errors.New("task not found")
+	return nil, ErrTaskNotFound
 }
 
 // RemoveTaskById removes any Task with given Task 'id' from the TaskList.
-// Returns an error if no Task was removed.
+// Returns ErrTaskNotFound if no Task was removed.
 func (tasklist *TaskList) RemoveTaskById(id int) error {
 	var newList TaskList
 
@@ -81,7 +103,7 @@ func (tasklist *TaskList) RemoveTaskById(id int) error {
 		}
 	}
 	if !found {
-		return errors.New("task not found")
+		return ErrTaskNotFound
 	}
 
 	*tasklist = newList
@@ -89,7 +111,7 @@ func (tasklist *TaskList) RemoveTaskById(id int) error {
 }
 
 // RemoveTask removes any Task from the TaskList with the same String representation as the given Task.
-// Returns an error if no Task was removed.
+// Returns ErrTaskNotFound if no Task was removed.
 func (tasklist *TaskList) RemoveTask(task Task) error {
 	var newList TaskList
 
@@ -102,16 +124,37 @@ func (tasklist *TaskList) RemoveTask(task Task) error {
 		}
 	}
 	if !found {
-		return errors.New("task not found")
+		return ErrTaskNotFound
 	}
 
 	*tasklist = newList
 	return nil
 }
 
+// Warnings returns the parse errors of all unparsed tasks in the TaskList.
+// See Lenient for further information.
+func (tasklist *TaskList) Warnings() ParseErrors {
+	var warnings ParseErrors
+	for _, t := range *tasklist {
+		if t.Unparsed && t.Warning != nil {
+			warnings = append(warnings, t.Warning)
+		}
+	}
+	return warnings
+}
+
 // Filter filters the current TaskList for the given predicate (a function that takes a task as input and returns a bool),
 // and returns a new TaskList. The original TaskList is not modified.
+//
+// Hidden tasks are left out, see Task.Hidden and *TaskList.FilterAll().
 func (tasklist *TaskList) Filter(predicate func(Task) bool) *TaskList {
+	return tasklist.FilterAll(func(t Task) bool {
+		return !t.Hidden && predicate(t)
+	})
+}
+
+// FilterAll works the same as *TaskList.Filter(), but includes hidden tasks.
+func (tasklist *TaskList) FilterAll(predicate func(Task) bool) *TaskList {
 	var newList TaskList
 	for _, t := range *tasklist {
 		if predicate(t) {
@@ -121,48 +164,87 @@ func (tasklist *TaskList) Filter(predicate func(Task) bool) *TaskList {
 	return &newList
 }
 
-// LoadFromFile loads a TaskList from *os.File.
-//
-// Using *os.File instead of a filename allows to also use os.Stdin.
-//
-// Note: This will clear the current TaskList and overwrite it's contents with whatever is in *os.File.
-func (tasklist *TaskList) LoadFromFile(file *os.File) error {
-	*tasklist = []Task{} // Empty tasklist
-
-	taskId := 1
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		text := strings.Trim(scanner.Text(), "\t\n\r ") // Read line
+// Visible returns a new TaskList without hidden tasks, see Task.Hidden.
+// The original TaskList is not modified.
+func (tasklist *TaskList) Visible() *TaskList {
+	return tasklist.Filter(func(t Task) bool {
+		return true
+	})
+}
 
-		// Ignore blank or comment lines
-		if text == "" || (IgnoreComments && strings.HasPrefix(text, "#")) {
-			continue
+// Projects returns the alphabetically sorted names of all projects used in the TaskList.
+// Hidden tasks are included, they are used to declare projects that have no tasks yet.
+func (tasklist *TaskList) Projects() []string {
+	var projects []string
+	for _, task := range *tasklist {
+		for _, project := range task.Projects {
+			if !containsString(projects, project) {
+				projects = append(projects, project)
+			}
 		}
+	}
+	sort.Strings(projects)
+	return projects
+}
 
-		task, err := ParseTask(text)
-		if err != nil {
-			return err
+// Contexts returns the alphabetically sorted names of all contexts used in the TaskList.
+// Hidden tasks are included, they are used to declare contexts that have no tasks yet.
+func (tasklist *TaskList) Contexts() []string {
+	var contexts []string
+	for _, task := range *tasklist {
+		for _, context := range task.Contexts {
+			if !containsString(contexts, context) {
+				contexts = append(contexts, context)
+			}
 		}
-		task.Id = taskId
-
-		*tasklist = append(*tasklist, *task)
-		taskId++
-	}
-	if err := scanner.Err(); err != nil {
-		return err
 	}
+	sort.Strings(contexts)
+	return contexts
+}
 
-	return nil
+// WithoutFutureThreshold returns a new TaskList without the tasks whose threshold date is still in the future.
+// The original TaskList is not modified.
+func (tasklist *TaskList) WithoutFutureThreshold() *TaskList {
+	return tasklist.WithoutFutureThresholdWith(nil)
+}
+
+// WithoutFutureThresholdWith works like WithoutFutureThreshold(), taking the current day from the given Clock.
+func (tasklist *TaskList) WithoutFutureThresholdWith(clock Clock) *TaskList {
+	return tasklist.Filter(func(t Task) bool {
+		return !t.IsFutureThresholdWith(clock)
+	})
+}
+
+// Overdue returns a new TaskList with all open tasks that are overdue, see *Task.IsOverdue().
+// The original TaskList is not modified.
+func (tasklist *TaskList) Overdue() *TaskList {
+	return tasklist.OverdueWith(nil)
+}
+
+// OverdueWith works like Overdue(), taking the current time from the given Clock.
+func (tasklist *TaskList) OverdueWith(clock Clock) *TaskList {
+	return tasklist.Filter(func(t Task) bool {
+		return !t.Completed && t.IsOverdueWith(clock)
+	})
+}
+
+// LoadFromFile loads a TaskList from *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdin.
+//
+// Note: This will clear the current TaskList and overwrite it's contents with whatever is in *os.File.
+//
+// Lines that can not be parsed are skipped, all of their errors are returned together as ParseErrors.
+// If Lenient is set to 'true', such lines are kept as unparsed tasks instead, and no error is returned.
+func (tasklist *TaskList) LoadFromFile(file *os.File) error {
+	return NewParser().loadTaskList(tasklist, file)
 }
 
 // WriteToFile writes a TaskList to *os.File.
 //
 // Using *os.File instead of a filename allows to also use os.Stdout.
 func (tasklist *TaskList) WriteToFile(file *os.File) error {
-	writer := bufio.NewWriter(file)
-	_, err := writer.WriteString(tasklist.String())
-	writer.Flush()
-	return err
+	return NewWriter().WriteToFile(tasklist, file)
 }
 
 // LoadFromFilename loads a TaskList from a file (most likely called "todo.txt").
@@ -180,18 +262,21 @@ func (tasklist *TaskList) LoadFromFilename(filename string) error {
 
 // WriteToFilename writes a TaskList to the specified file (most likely called "todo.txt").
 func (tasklist *TaskList) WriteToFilename(filename string) error {
-	return ioutil.WriteFile(filename, []byte(tasklist.String()), 0640)
+	return NewWriter().WriteToFilename(tasklist, filename)
 }
 
 // LoadFromFile loads and returns a TaskList from *os.File.
 //
 // Using *os.File instead of a filename allows to also use os.Stdin.
 func LoadFromFile(file *os.File) (TaskList, error) {
-	tasklist := TaskList{}
-	if err := tasklist.LoadFromFile(file); err != nil {
-		return nil, err
-	}
-	return tasklist, nil
+	return NewParser().LoadFromFile(file)
+}
+
+// LoadFromReader loads and returns a TaskList from any io.Reader.
+//
+// See NewDecoder for reading tasks one by one instead.
+func LoadFromReader(r io.Reader) (TaskList, error) {
+	return NewParser().LoadFromReader(r)
 }
 
 // WriteToFile writes a TaskList to *os.File.
@@ -203,11 +288,7 @@ func WriteToFile(tasklist *TaskList, file *os.File) error {
 
 // LoadFromFilename loads and returns a TaskList from a file (most likely called "todo.txt").
 func LoadFromFilename(filename string) (TaskList, error) {
-	tasklist := TaskList{}
-	if err := tasklist.LoadFromFilename(filename); err != nil {
-		return nil, err
-	}
-	return tasklist, nil
+	return NewParser().LoadFromFilename(filename)
 }
 
 // WriteToFilename writes a TaskList to the specified file (most likely called "todo.txt").
@@ -5,10 +5,15 @@
 package todotxt
 
 import (
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/JamesClonk/go-todotxt/todotxttest"
 )
 
 var (
@@ -17,6 +22,7 @@ var (
 	testInputTasklistDueDateError       = "testdata/tasklist_dueDate_error.txt"
 	testInputTasklistCompletedDateError = "testdata/tasklist_completedDate_error.txt"
 	testInputTasklistScannerError       = "testdata/tasklist_scanner_error.txt"
+	testInputTasklistMultipleError      = "testdata/tasklist_multiple_error.txt"
 	testOutput                          = "testdata/ouput_todo.txt"
 	testExpectedOutput                  = "testdata/expected_todo.txt"
 	testTasklist                        TaskList
@@ -204,6 +210,38 @@ func TestTaskListWriteFilename(t *testing.T) {
 	}
 }
 
+func TestTaskListWriteFilenamePreserveOrder(t *testing.T) {
+	PreserveOrder = true
+	defer func() { PreserveOrder = false }()
+	os.Remove(testOutput)
+	testTasklist := TaskList{}
+
+	if err := testTasklist.LoadFromFilename(testInputTasklist); err != nil {
+		t.Fatal(err)
+	}
+	if err := testTasklist.WriteToFilename(testOutput); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(testInputTasklist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		expected += strings.Trim(line, "\t\n\r ") + "\n"
+	}
+	data, err = ioutil.ReadFile(testOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = expected
+	testGot = string(data)
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
 func TestNewTaskList(t *testing.T) {
 	testTasklist := NewTaskList()
 
@@ -409,8 +447,8 @@ func TestTaskListRemoveTaskById(t *testing.T) {
 	}
 
 	taskId = 99
-	if err := testTasklist.RemoveTaskById(taskId); err == nil {
-		t.Errorf("Expected no Task to be found for removal")
+	if err := testTasklist.RemoveTaskById(taskId); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected no Task to be found for removal, but got [%v]", err)
 	}
 }
 
@@ -457,8 +495,8 @@ func TestTaskListRemoveTask(t *testing.T) {
 		t.Errorf("Expected no Task to be found anymore, but got %v", task)
 	}
 
-	if err := testTasklist.RemoveTask(NewTask()); err == nil {
-		t.Errorf("Expected no Task to be found for removal")
+	if err := testTasklist.RemoveTask(NewTask()); !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected no Task to be found for removal, but got [%v]", err)
 	}
 }
 
@@ -497,26 +535,237 @@ func TestTaskListFilter(t *testing.T) {
 func TestTaskListReadErrors(t *testing.T) {
 	if testTasklist, err := LoadFromFilename(testInputTasklistCreatedDateError); testTasklist != nil || err == nil {
 		t.Errorf("Expected LoadFromFilename to fail because of invalid created date, but got TaskList back: [%s]", testTasklist)
-	} else if err.Error() != `parsing time "2013-13-01": month out of range` {
+	} else if err.Error() != `line 3, column 5: invalid created date: parsing time "2013-13-01": month out of range` {
 		t.Error(err)
 	}
 
 	if testTasklist, err := LoadFromFilename(testInputTasklistDueDateError); testTasklist != nil || err == nil {
 		t.Errorf("Expected LoadFromFilename to fail because of invalid due date, but got TaskList back: [%s]", testTasklist)
-	} else if err.Error() != `parsing time "2014-02-32": day out of range` {
+	} else if err.Error() != `line 4, column 77: invalid due date: parsing time "2014-02-32": day out of range` {
 		t.Error(err)
 	}
 
 	if testTasklist, err := LoadFromFilename(testInputTasklistCompletedDateError); testTasklist != nil || err == nil {
 		t.Errorf("Expected LoadFromFilename to fail because of invalid completed date, but got TaskList back: [%s]", testTasklist)
-	} else if err.Error() != `parsing time "2014-25-04": month out of range` {
+	} else if err.Error() != `line 6, column 3: invalid completed date: parsing time "2014-25-04": month out of range` {
 		t.Error(err)
 	}
 
-	// really silly test
+	// really silly test, lines longer than 64 KiB are read completely
 	if testTasklist, err := LoadFromFilename(testInputTasklistScannerError); testTasklist != nil || err == nil {
-		t.Errorf("Expected LoadFromFilename to fail because of invalid file, but got TaskList back: [%s]", testTasklist)
-	} else if err.Error() != `bufio.Scanner: token too long` {
+		t.Errorf("Expected LoadFromFilename to fail because of invalid due date, but got TaskList back: [%s]", testTasklist)
+	} else if err.Error() != `line 1, column 77: invalid due date: parsing time "2014-02-17x": extra text: "x"` {
 		t.Error(err)
 	}
 }
+
+func TestTaskListParseErrors(t *testing.T) {
+	testTasklist, err := LoadFromFilename(testInputTasklistMultipleError)
+	if testTasklist != nil || err == nil {
+		t.Fatalf("Expected LoadFromFilename to fail because of invalid dates, but got TaskList back: [%s]", testTasklist)
+	}
+
+	var parseErrors ParseErrors
+	if !errors.As(err, &parseErrors) {
+		t.Fatalf("Expected error to be ParseErrors, but got [%T]", err)
+	}
+	testExpected = 2
+	testGot = len(parseErrors)
+	if testGot != testExpected {
+		t.Errorf("Expected %d parse errors, but got %d", testExpected, testGot)
+	}
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("Expected error to contain a *ParseError, but got [%T]", err)
+	}
+	testExpected = "line 2, column 5: invalid created date: parsing time \"2013-13-01\": month out of range"
+	testGot = parseError.Error()
+	if testGot != testExpected {
+		t.Errorf("Expected first error to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	parseError = parseErrors[1]
+	testExpected = 4
+	testGot = parseError.Line
+	if testGot != testExpected {
+		t.Errorf("Expected error on line %d, but got %d", testExpected, testGot)
+	}
+	testExpected = 16
+	testGot = parseError.Column
+	if testGot != testExpected {
+		t.Errorf("Expected error in column %d, but got %d", testExpected, testGot)
+	}
+	testExpected = "due"
+	testGot = parseError.Field
+	if testGot != testExpected {
+		t.Errorf("Expected error for field [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = "  Call Mom due:2014-02-32 @Phone"
+	testGot = parseError.Text
+	if testGot != testExpected {
+		t.Errorf("Expected error for line [%s], but got [%s]", testExpected, testGot)
+	}
+
+	var timeError *time.ParseError
+	if !errors.As(err, &timeError) {
+		t.Errorf("Expected error to wrap a *time.ParseError, but got [%v]", err)
+	}
+}
+
+func TestTaskListHidden(t *testing.T) {
+	tasklist, err := LoadFromReader(strings.NewReader("@Garden +Landscaping h:1\nCall Mom @Phone +Family\nx Buy flowers +Family\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = 2
+	testGot = len(*tasklist.Visible())
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to contain %d visible tasks, but got %d", testExpected, testGot)
+	}
+	testExpected = 1
+	testGot = len(*tasklist.Filter(func(t Task) bool { return !t.Completed }))
+	if testGot != testExpected {
+		t.Errorf("Expected Filter() to leave out hidden tasks and return %d tasks, but got %d", testExpected, testGot)
+	}
+	testExpected = 2
+	testGot = len(*tasklist.FilterAll(func(t Task) bool { return !t.Completed }))
+	if testGot != testExpected {
+		t.Errorf("Expected FilterAll() to include hidden tasks and return %d tasks, but got %d", testExpected, testGot)
+	}
+
+	testExpected = "[Family Landscaping]"
+	testGot = fmt.Sprint(tasklist.Projects())
+	if testGot != testExpected {
+		t.Errorf("Expected projects to be %s, but got %s", testExpected, testGot)
+	}
+	testExpected = "[Garden Phone]"
+	testGot = fmt.Sprint(tasklist.Contexts())
+	if testGot != testExpected {
+		t.Errorf("Expected contexts to be %s, but got %s", testExpected, testGot)
+	}
+}
+
+func TestTaskListWithoutFutureThreshold(t *testing.T) {
+	tasklist := TaskList{
+		{Id: 1, Todo: "No threshold"},
+		{Id: 2, Todo: "Past threshold", ThresholdDate: time.Now().AddDate(0, 0, -1)},
+		{Id: 3, Todo: "Future threshold", ThresholdDate: time.Now().AddDate(0, 1, 0)},
+	}
+
+	activeList := tasklist.WithoutFutureThreshold()
+	testExpected = 2
+	testGot = len(*activeList)
+	if testGot != testExpected {
+		t.Fatalf("Expected TaskList to contain %d tasks, but got %d", testExpected, testGot)
+	}
+	testExpected = "Past threshold"
+	testGot = (*activeList)[1].Todo
+	if testGot != testExpected {
+		t.Errorf("Expected Task[2] to be [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = 3
+	testGot = len(tasklist)
+	if testGot != testExpected {
+		t.Errorf("Expected original TaskList to still contain %d tasks, but got %d", testExpected, testGot)
+	}
+}
+
+func TestTaskListOverdueWith(t *testing.T) {
+	defer func(location *time.Location) { Location = location }(Location)
+	Location = time.UTC
+	date := func(day int) time.Time { return time.Date(2024, 3, day, 0, 0, 0, 0, time.UTC) }
+	tasklist := TaskList{
+		{Id: 1, Todo: "No due date"},
+		{Id: 2, Todo: "Due Friday", DueDate: date(8)},
+		{Id: 3, Todo: "Due Monday", DueDate: date(11), ThresholdDate: date(10)},
+		{Id: 4, Todo: "Done", DueDate: date(1), Completed: true},
+	}
+	friday := todotxttest.FixedClock(time.Date(2024, 3, 8, 12, 0, 0, 0, time.UTC))
+	monday := todotxttest.FixedClock(time.Date(2024, 3, 11, 12, 0, 0, 0, time.UTC))
+	tuesday := todotxttest.FixedClock(time.Date(2024, 3, 12, 12, 0, 0, 0, time.UTC))
+
+	for _, test := range []struct {
+		clock    Clock
+		overdue  int
+		inactive int
+	}{{friday, 0, 1}, {monday, 1, 0}, {tuesday, 2, 0}} {
+		testExpected = test.overdue
+		testGot = len(*tasklist.OverdueWith(test.clock))
+		if testGot != testExpected {
+			t.Errorf("Expected %d tasks to be overdue at [%v], but got %d", testExpected, test.clock.Now(), testGot)
+		}
+		testExpected = len(tasklist) - test.inactive
+		testGot = len(*tasklist.WithoutFutureThresholdWith(test.clock))
+		if testGot != testExpected {
+			t.Errorf("Expected %d tasks without future threshold at [%v], but got %d", testExpected, test.clock.Now(), testGot)
+		}
+	}
+}
+
+func TestTaskListGetTaskNotFound(t *testing.T) {
+	if err := testTasklist.LoadFromFilename(testInputTasklist); err != nil {
+		t.Fatal(err)
+	}
+
+	if task, err := testTasklist.GetTask(99); task != nil || !errors.Is(err, ErrTaskNotFound) {
+		t.Errorf("Expected no Task to be found, but got [%v] and error [%v]", task, err)
+	}
+}
+
+func TestTaskListLenient(t *testing.T) {
+	Lenient = true
+	defer func() { Lenient = false }()
+
+	testTasklist, err := LoadFromFilename(testInputTasklistMultipleError)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = 4
+	testGot = len(testTasklist)
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to contain %d tasks, but got %d", testExpected, testGot)
+	}
+
+	testExpected = true
+	testGot = testTasklist[1].Unparsed
+	if testGot != testExpected {
+		t.Errorf("Expected Task[2] to be unparsed, but got [%v]", testGot)
+	}
+	testExpected = false
+	testGot = testTasklist[2].Unparsed
+	if testGot != testExpected {
+		t.Errorf("Expected Task[3] to be parsed, but got [%v]", testGot)
+	}
+
+	warnings := testTasklist.Warnings()
+	testExpected = 2
+	testGot = len(warnings)
+	if testGot != testExpected {
+		t.Fatalf("Expected %d warnings, but got %d", testExpected, testGot)
+	}
+	testExpected = 4
+	testGot = warnings[1].Line
+	if testGot != testExpected {
+		t.Errorf("Expected warning on line %d, but got %d", testExpected, testGot)
+	}
+
+	os.Remove(testOutput)
+	if err := testTasklist.WriteToFilename(testOutput); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(testOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "2013-02-22 Pick up milk @GroceryStore\n" +
+		"(B) 2013-13-01 Outline chapter 5 +Novel @Computer\n" +
+		"x Download Todo.txt mobile app @Phone\n" +
+		"Call Mom due:2014-02-32 @Phone\n"
+	testGot = string(data)
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
@@ -0,0 +1,61 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+// Package todotxttest provides helpers for testing code that uses the todotxt package.
+package todotxttest
+
+import (
+	"sync"
+	"time"
+)
+
+// FixedClock is a todotxt.Clock that always returns the same time.
+//
+//	task.IsOverdueWith(todotxttest.FixedClock(nextMonday))
+type FixedClock time.Time
+
+// Now returns the fixed time.
+func (clock FixedClock) Now() time.Time {
+	return time.Time(clock)
+}
+
+// FakeClock is a todotxt.Clock whose time only changes when it is set or advanced.
+// It can be used concurrently by multiple goroutines.
+type FakeClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+// NewFakeClock creates a new FakeClock, starting at the given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the current time of the clock.
+func (clock *FakeClock) Now() time.Time {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	return clock.now
+}
+
+// Set sets the current time of the clock.
+func (clock *FakeClock) Set(now time.Time) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.now = now
+}
+
+// Advance moves the clock forward by the given duration, or backwards if it is negative.
+func (clock *FakeClock) Advance(d time.Duration) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.now = clock.now.Add(d)
+}
+
+// AdvanceDays moves the clock forward by the given number of calendar days, keeping the time of day.
+func (clock *FakeClock) AdvanceDays(days int) {
+	clock.mutex.Lock()
+	defer clock.mutex.Unlock()
+	clock.now = clock.now.AddDate(0, 0, days)
+}
@@ -0,0 +1,43 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxttest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFixedClock(t *testing.T) {
+	now := time.Date(2024, 3, 11, 9, 30, 0, 0, time.UTC)
+	clock := FixedClock(now)
+	if got := clock.Now(); !got.Equal(now) {
+		t.Errorf("Expected FixedClock to return [%v], but got [%v]", now, got)
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	now := time.Date(2024, 3, 11, 9, 30, 0, 0, time.UTC)
+	clock := NewFakeClock(now)
+	if got := clock.Now(); !got.Equal(now) {
+		t.Errorf("Expected FakeClock to return [%v], but got [%v]", now, got)
+	}
+
+	clock.Advance(90 * time.Minute)
+	expected := time.Date(2024, 3, 11, 11, 0, 0, 0, time.UTC)
+	if got := clock.Now(); !got.Equal(expected) {
+		t.Errorf("Expected FakeClock to return [%v] after Advance(), but got [%v]", expected, got)
+	}
+
+	clock.AdvanceDays(21)
+	expected = time.Date(2024, 4, 1, 11, 0, 0, 0, time.UTC)
+	if got := clock.Now(); !got.Equal(expected) {
+		t.Errorf("Expected FakeClock to return [%v] after AdvanceDays(), but got [%v]", expected, got)
+	}
+
+	clock.Set(now)
+	if got := clock.Now(); !got.Equal(now) {
+		t.Errorf("Expected FakeClock to return [%v] after Set(), but got [%v]", now, got)
+	}
+}
@@ -0,0 +1,394 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Writer holds the options used for writing tasks in todo.txt format.
+//
+// A Writer can be shared between goroutines, as long as its options are not modified anymore.
+type Writer struct {
+	DateLayout string  // Layout used for formatting dates.
+	SortTokens bool    // Sort contexts, projects and tags and append them at the end, instead of keeping the order of Task.Original.
+	LineEnding string  // Line ending written after each task: "\n" or "\r\n".
+	Parser     *Parser // Parser used to read Task.Original again and to escape Task.Todo. The Parser each task was read with is used if nil.
+}
+
+// NewWriter creates a new Writer, using the package level variables DateLayout and PreserveOrder as defaults.
+func NewWriter() *Writer {
+	return &Writer{
+		DateLayout: DateLayout,
+		SortTokens: !PreserveOrder,
+		LineEnding: "\n",
+	}
+}
+
+// writer returns a Writer for tasks read by the Parser, which keeps the token order of Task.Original
+// and writes dates in the first date layout of the Parser, so that the tasks can be read again with it.
+func (parser *Parser) writer() *Writer {
+	writer := NewWriter()
+	writer.SortTokens = false
+	writer.Parser = parser
+	if len(parser.DateLayouts) > 0 {
+		writer.DateLayout = parser.DateLayouts[0]
+	}
+	return writer
+}
+
+// Format returns a complete task string in todo.txt format.
+// See *Task.String() and *Task.PreservedString() for further information.
+func (writer *Writer) Format(task Task) string {
+	if task.Unparsed {
+		return task.Original
+	}
+	if writer.SortTokens || task.Original == "" {
+		return writer.sorted(task)
+	}
+	return writer.preserved(task)
+}
+
+// FormatList returns a complete list of tasks in todo.txt format.
+func (writer *Writer) FormatList(tasklist TaskList) string {
+	var text strings.Builder
+	for _, task := range tasklist {
+		text.WriteString(writer.Format(task))
+		text.WriteString(writer.lineEnding())
+	}
+	return text.String()
+}
+
+// FormatDocument returns the complete text of a Document.
+//
+// Unmodified tasks, comments and blank lines are written back exactly as they were read,
+// only modified tasks are formatted anew.
+func (writer *Writer) FormatDocument(doc *Document) string {
+	var text strings.Builder
+	for _, line := range doc.Lines {
+		text.WriteString(writer.formatLine(line))
+		text.WriteString(line.Ending)
+	}
+	return text.String()
+}
+
+// formatLine returns the text of a single Line of a Document, without line ending.
+func (writer *Writer) formatLine(line *Line) string {
+	if line.Type != LINE_TASK || line.Task == nil || line.Task.Unparsed {
+		return line.Text
+	}
+	if !line.Task.Dirty && writer.preserved(*line.Task) == line.Task.Original {
+		return line.Text
+	}
+	return writer.Format(*line.Task)
+}
+
+// WriteToFile writes a TaskList to *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdout.
+func (writer *Writer) WriteToFile(tasklist *TaskList, file *os.File) error {
+	w := bufio.NewWriter(file)
+	if err := writer.NewEncoder(w).EncodeList(*tasklist); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// WriteToFilename writes a TaskList to the specified file (most likely called "todo.txt").
+func (writer *Writer) WriteToFilename(tasklist *TaskList, filename string) error {
+	return ioutil.WriteFile(filename, []byte(writer.FormatList(*tasklist)), 0640)
+}
+
+// WriteDocumentToFile writes a Document to *os.File.
+//
+// Using *os.File instead of a filename allows to also use os.Stdout.
+func (writer *Writer) WriteDocumentToFile(doc *Document, file *os.File) error {
+	return writeToFile(writer.FormatDocument(doc), file)
+}
+
+// WriteDocumentToFilename writes a Document to the specified file (most likely called "todo.txt").
+func (writer *Writer) WriteDocumentToFilename(doc *Document, filename string) error {
+	return ioutil.WriteFile(filename, []byte(writer.FormatDocument(doc)), 0640)
+}
+
+func writeToFile(text string, file *os.File) error {
+	w := bufio.NewWriter(file)
+	if _, err := w.WriteString(text); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (writer *Writer) lineEnding() string {
+	if writer.LineEnding == "" {
+		return "\n"
+	}
+	return writer.LineEnding
+}
+
+// parser returns the Parser used for the task, which is the Parser the task was read with if Writer.Parser is not set.
+func (writer *Writer) parser(task Task) *Parser {
+	if writer.Parser == nil {
+		return task.parser()
+	}
+	return writer.Parser
+}
+
+// sorted returns the task string with all contexts, projects and additional tags sorted and appended at the end.
+func (writer *Writer) sorted(task Task) string {
+	var text strings.Builder
+	writer.prefix(&text, task)
+	writer.suffix(&text, task)
+	return text.String()
+}
+
+// prefix writes the completion, priority and created date part of the task string, including a trailing space.
+func (writer *Writer) prefix(text *strings.Builder, task Task) {
+	if task.Completed {
+		text.WriteString("x ")
+		if task.HasCompletedDate() {
+			text.WriteString(task.CompletedDate.Format(writer.DateLayout))
+			text.WriteByte(' ')
+		}
+	}
+
+	if task.HasPriority() {
+		text.WriteByte('(')
+		text.WriteString(task.Priority)
+		text.WriteString(") ")
+	}
+
+	if task.HasCreatedDate() {
+		text.WriteString(task.CreatedDate.Format(writer.DateLayout))
+		text.WriteByte(' ')
+	}
+}
+
+// suffix writes the todo text of the task, followed by all contexts, projects and additional tags.
+func (writer *Writer) suffix(text *strings.Builder, task Task) {
+	writer.parser(task).escape(text, task.Todo)
+
+	// separate writes a space in front of each element, unless it is the very first one or follows the prefix
+	separate := func(element string) {
+		if text.Len() > 0 && !strings.HasSuffix(text.String(), " ") {
+			text.WriteByte(' ')
+		}
+		text.WriteString(element)
+	}
+
+	for _, context := range sortedStrings(task.Contexts) {
+		separate("@")
+		text.WriteString(context)
+	}
+
+	for _, project := range sortedStrings(task.Projects) {
+		separate("+")
+		text.WriteString(project)
+	}
+
+	// Sort map alphabetically by keys
+	for _, key := range sortedKeys(task.AdditionalTags) {
+		separate(key)
+		text.WriteByte(':')
+		text.WriteString(task.AdditionalTags[key])
+	}
+
+	if task.Hidden {
+		separate("h:1")
+	}
+
+	if task.HasThresholdDate() {
+		separate("t:")
+		text.WriteString(task.ThresholdDate.Format(writer.DateLayout))
+	}
+
+	if task.HasDueDate() {
+		separate("due:")
+		text.WriteString(writer.dueDate(task))
+	}
+}
+
+// dueDate returns the formatted due date of the task, including its time of day if it has one.
+func (writer *Writer) dueDate(task Task) string {
+	if task.HasDueTime() {
+		return task.DueDate.Format(writer.DateLayout + "T" + DueTimeLayout)
+	}
+	return task.DueDate.Format(writer.DateLayout)
+}
+
+// escape writes the todo text, with a backslash in front of each word that would otherwise not be read back as todo text by the Parser.
+// See *Parser.ParseTask() for further information.
+func (parser *Parser) escape(text *strings.Builder, todo string) {
+	first := true
+	for pos := 0; pos < len(todo); {
+		start := pos
+		for start < len(todo) && isSpace(todo[start]) {
+			start++
+		}
+		end := start
+		for end < len(todo) && !isSpace(todo[end]) {
+			end++
+		}
+		text.WriteString(todo[pos:start])
+		if end > start {
+			if parser.needsEscape(todo[start:end], first) {
+				text.WriteByte('\\')
+			}
+			text.WriteString(todo[start:end])
+			first = false
+		}
+		pos = end
+	}
+}
+
+// escaped returns the escaped todo text, see *Parser.escape().
+func (parser *Parser) escaped(todo string) string {
+	var text strings.Builder
+	parser.escape(&text, todo)
+	return text.String()
+}
+
+// preserved returns the task string in the token order of Task.Original.
+// See *Task.PreservedString() for further information.
+func (writer *Writer) preserved(task Task) string {
+	if task.Original == "" {
+		return writer.sorted(task)
+	}
+	parser := writer.parser(task)
+	original, err := parser.ParseTask(task.Original)
+	if err != nil {
+		return writer.sorted(task)
+	}
+	if task.equals(original) {
+		return task.Original
+	}
+
+	todoChanged := task.Todo != original.Todo
+	todoWritten := false
+	seenContexts := make(map[string]bool)
+	seenProjects := make(map[string]bool)
+	seenTags := make(map[string]bool)
+	seenDue := false
+	seenThreshold := false
+	seenHidden := false
+
+	// A time of day read from an 'at:' tag is written back as such, instead of as part of the due date
+	tokens := parser.Tokenize(task.Original)
+	_, atKept := original.AdditionalTags["at"]
+	atTime := false
+	for _, token := range tokens {
+		if token.Type == TOKEN_TAG && token.Key == "at" && !atKept {
+			atTime = true
+		}
+	}
+
+	var parts []part
+	end := 0
+	for _, token := range tokens {
+		p := part{task.Original[end:token.Start], token.Text}
+		end = token.End
+
+		switch token.Type {
+		case TOKEN_CONTEXT:
+			if containsString(task.Contexts, token.Value) && !seenContexts[token.Value] {
+				seenContexts[token.Value] = true
+				parts = append(parts, p)
+			}
+		case TOKEN_PROJECT:
+			if containsString(task.Projects, token.Value) && !seenProjects[token.Value] {
+				seenProjects[token.Value] = true
+				parts = append(parts, p)
+			}
+		case TOKEN_DUE_DATE:
+			if task.HasDueDate() && !seenDue {
+				seenDue = true
+				if atTime && task.HasDueTime() {
+					parts = append(parts, part{p.sep, "due:" + task.DueDate.Format(writer.DateLayout)})
+				} else {
+					parts = append(parts, part{p.sep, "due:" + writer.dueDate(task)})
+				}
+			}
+		case TOKEN_HIDDEN:
+			if task.Hidden && !seenHidden {
+				seenHidden = true
+				parts = append(parts, p)
+			}
+		case TOKEN_THRESHOLD_DATE:
+			if task.HasThresholdDate() && !seenThreshold {
+				seenThreshold = true
+				parts = append(parts, part{p.sep, "t:" + task.ThresholdDate.Format(writer.DateLayout)})
+			}
+		case TOKEN_TAG:
+			if token.Key == "at" && atTime {
+				if task.HasDueTime() && !seenTags["at"] {
+					seenTags["at"] = true
+					parts = append(parts, part{p.sep, "at:" + task.DueDate.Format(DueTimeLayout)})
+				}
+			} else if value, found := task.AdditionalTags[token.Key]; found && !seenTags[token.Key] {
+				seenTags[token.Key] = true
+				parts = append(parts, part{p.sep, token.Key + ":" + value})
+			}
+		case TOKEN_WORD, TOKEN_LINK:
+			if !todoChanged {
+				parts = append(parts, p)
+			} else if !todoWritten {
+				todoWritten = true
+				if task.Todo != "" {
+					parts = append(parts, part{p.sep, parser.escaped(task.Todo)})
+				}
+			}
+		}
+	}
+	if todoChanged && !todoWritten && task.Todo != "" {
+		parts = append([]part{{" ", parser.escaped(task.Todo)}}, parts...)
+	}
+
+	// Append everything that was not part of the original text
+	for _, context := range sortedStrings(task.Contexts) {
+		if !seenContexts[context] {
+			seenContexts[context] = true
+			parts = append(parts, part{" ", "@" + context})
+		}
+	}
+	for _, project := range sortedStrings(task.Projects) {
+		if !seenProjects[project] {
+			seenProjects[project] = true
+			parts = append(parts, part{" ", "+" + project})
+		}
+	}
+	for _, key := range sortedKeys(task.AdditionalTags) {
+		if !seenTags[key] {
+			parts = append(parts, part{" ", key + ":" + task.AdditionalTags[key]})
+		}
+	}
+	if task.Hidden && !seenHidden {
+		parts = append(parts, part{" ", "h:1"})
+	}
+	if task.HasThresholdDate() && !seenThreshold {
+		parts = append(parts, part{" ", "t:" + task.ThresholdDate.Format(writer.DateLayout)})
+	}
+	if task.HasDueDate() && !seenDue {
+		parts = append(parts, part{" ", "due:" + writer.dueDate(task)})
+	}
+
+	var text strings.Builder
+	writer.prefix(&text, task)
+	for i, p := range parts {
+		if i > 0 {
+			text.WriteString(p.sep)
+		}
+		text.WriteString(p.text)
+	}
+	return strings.Trim(text.String(), "\t\n\r ")
+}
+
+// part is a token of a task string, together with the whitespace preceding it.
+type part struct {
+	sep  string
+	text string
+}
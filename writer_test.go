@@ -0,0 +1,105 @@
+/* This Source Code Form is subject to the terms of the Mozilla Public
+ * License, v. 2.0. If a copy of the MPL was not distributed with this
+ * file, You can obtain one at http://mozilla.org/MPL/2.0/. */
+
+package todotxt
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewWriter(t *testing.T) {
+	writer := NewWriter()
+
+	testExpected = true
+	testGot = writer.SortTokens
+	if testGot != testExpected {
+		t.Errorf("Expected Writer to sort tokens, but got [%v]", testGot)
+	}
+
+	PreserveOrder = true
+	defer func() { PreserveOrder = false }()
+	writer = NewWriter()
+
+	testExpected = false
+	testGot = writer.SortTokens
+	if testGot != testExpected {
+		t.Errorf("Expected Writer not to sort tokens, but got [%v]", testGot)
+	}
+}
+
+func TestWriterFormat(t *testing.T) {
+	task, err := ParseTask("x 2014-01-03 (C) 2014-01-01 @Go due:2014-01-12 Create golang library documentation +go-todotxt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := &Writer{DateLayout: "02.01.2006", SortTokens: true}
+	testExpected = "x 03.01.2014 (C) 01.01.2014 Create golang library documentation @Go +go-todotxt due:12.01.2014"
+	testGot = writer.Format(*task)
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	writer.SortTokens = false
+	testExpected = "x 2014-01-03 (C) 2014-01-01 @Go due:2014-01-12 Create golang library documentation +go-todotxt"
+	testGot = writer.Format(*task)
+	if testGot != testExpected {
+		t.Errorf("Expected unmodified Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+
+	task.Priority = "A"
+	testExpected = "x 03.01.2014 (A) 01.01.2014 @Go due:12.01.2014 Create golang library documentation +go-todotxt"
+	testGot = writer.Format(*task)
+	if testGot != testExpected {
+		t.Errorf("Expected modified Task to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestWriterWriteToFilename(t *testing.T) {
+	testTasklist, err := LoadFromFilename(testInputTask)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testTasklist = testTasklist[:2]
+
+	writer := NewWriter()
+	writer.LineEnding = "\r\n"
+	os.Remove(testOutput)
+	if err := writer.WriteToFilename(&testTasklist, testOutput); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(testOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testExpected = "2013-02-22 Pick up milk @GroceryStore\r\nx Download Todo.txt mobile app @Phone\r\n"
+	testGot = string(data)
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to be [%s], but got [%s]", testExpected, testGot)
+	}
+}
+
+func TestWriterFormatParser(t *testing.T) {
+	parser := NewParser()
+	parser.TagKey = func(key string) bool { return key != "" }
+	tasklist, err := parser.LoadFromReader(strings.NewReader("Meet \\10:30 Bob\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testExpected = "Meet \\10:30 Bob"
+	testGot = tasklist[0].String()
+	if testGot != testExpected {
+		t.Errorf("Expected Task to be escaped by its Parser as [%s], but got [%s]", testExpected, testGot)
+	}
+	testExpected = "Meet \\10:30 Bob\n"
+	testGot = tasklist.String()
+	if testGot != testExpected {
+		t.Errorf("Expected TaskList to be escaped by its Parser as [%s], but got [%s]", testExpected, testGot)
+	}
+}